@@ -0,0 +1,101 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// StrategyFunc adapts a pair of functions to the Strategy interface:
+//
+//	version.Register("mem", version.StrategyFunc{
+//		VersionFn:    func(*sql.DB) (int, error) { return current, nil },
+//		SetVersionFn: func(_ *sql.DB, v int) error { current = v; return nil },
+//	})
+//
+// Calling a method whose function is nil panics
+type StrategyFunc struct {
+	VersionFn    func(*sql.DB) (int, error)
+	SetVersionFn func(*sql.DB, int) error
+}
+
+// Version calls f.VersionFn
+func (f StrategyFunc) Version(db *sql.DB) (int, error) {
+	if f.VersionFn == nil {
+		panic("versioned db: StrategyFunc.Version called with nil VersionFn")
+	}
+	return f.VersionFn(db)
+}
+
+// SetVersion calls f.SetVersionFn
+func (f StrategyFunc) SetVersion(db *sql.DB, version int) error {
+	if f.SetVersionFn == nil {
+		panic("versioned db: StrategyFunc.SetVersion called with nil SetVersionFn")
+	}
+	return f.SetVersionFn(db, version)
+}
+
+// ErrNilCallback is returned by the OnCreate and OnUpdate methods of a
+// FuncScheme whose corresponding function is nil
+var ErrNilCallback = errors.New("versioned db: scheme callback is nil")
+
+// FuncScheme adapts functions to the Scheme interface. A nil VersionFn
+// or VersionStrategyFn reports version zero or an empty strategy name,
+// which PersistScheme rejects, while nil callbacks return ErrNilCallback
+type FuncScheme struct {
+	VersionFn         func() int
+	VersionStrategyFn func() string
+	OnCreateFn        func(*sql.DB) error
+	OnUpdateFn        func(*sql.DB, int) error
+}
+
+// Version calls f.VersionFn
+func (f FuncScheme) Version() SchemeVersion {
+	if f.VersionFn == nil {
+		return 0
+	}
+	return SchemeVersion(f.VersionFn())
+}
+
+// VersionStrategy calls f.VersionStrategyFn
+func (f FuncScheme) VersionStrategy() string {
+	if f.VersionStrategyFn == nil {
+		return ""
+	}
+	return f.VersionStrategyFn()
+}
+
+// OnCreate calls f.OnCreateFn
+func (f FuncScheme) OnCreate(db *sql.DB) error {
+	if f.OnCreateFn == nil {
+		return ErrNilCallback
+	}
+	return f.OnCreateFn(db)
+}
+
+// OnUpdate calls f.OnUpdateFn
+func (f FuncScheme) OnUpdate(db *sql.DB, oldVersion int) error {
+	if f.OnUpdateFn == nil {
+		return ErrNilCallback
+	}
+	return f.OnUpdateFn(db, oldVersion)
+}
+
+// MigrateFunc migrates a database from fromVersion, zero meaning the
+// scheme must be created, allowing every step to be declared in a
+// single switch
+type MigrateFunc func(db *sql.DB, fromVersion int) error
+
+// NewMigrateFuncScheme returns a scheme of the given version and
+// strategy whose OnCreate calls fn(db, 0) and whose OnUpdate calls
+// fn(db, oldVersion). It returns ErrNilCallback if fn is nil
+func NewMigrateFuncScheme(version int, strategyName string, fn MigrateFunc) (Scheme, error) {
+	if fn == nil {
+		return nil, ErrNilCallback
+	}
+	return FuncScheme{
+		VersionFn:         func() int { return version },
+		VersionStrategyFn: func() string { return strategyName },
+		OnCreateFn:        func(db *sql.DB) error { return fn(db, 0) },
+		OnUpdateFn:        fn,
+	}, nil
+}
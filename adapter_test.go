@@ -0,0 +1,102 @@
+package version
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrategyFunc(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	current := 0
+	Register("mem", StrategyFunc{
+		VersionFn:    func(*sql.DB) (int, error) { return current, nil },
+		SetVersionFn: func(_ *sql.DB, v int) error { current = v; return nil },
+	})
+
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("mem").
+		On("OnCreate", db).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, 2, current, "SetVersionFn must be called")
+
+	scheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestStrategyFuncNilFunctions(t *testing.T) {
+	assert.PanicsWithValue(t, "versioned db: StrategyFunc.Version called with nil VersionFn", func() {
+		StrategyFunc{}.Version(nil)
+	})
+	assert.PanicsWithValue(t, "versioned db: StrategyFunc.SetVersion called with nil SetVersionFn", func() {
+		StrategyFunc{}.SetVersion(nil, 1)
+	})
+}
+
+func TestFuncScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	var updatedFrom int
+	s := FuncScheme{
+		VersionFn:         func() int { return 2 },
+		VersionStrategyFn: func() string { return "fake" },
+		OnUpdateFn:        func(_ *sql.DB, oldVersion int) error { updatedFrom = oldVersion; return nil },
+	}
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, s)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, 1, updatedFrom, "OnUpdateFn must be called")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestFuncSchemeNilFunctions(t *testing.T) {
+	var s FuncScheme
+
+	assert.Equal(t, SchemeVersion(0), s.Version())
+	assert.Equal(t, "", s.VersionStrategy())
+	assert.Equal(t, ErrNilCallback, s.OnCreate(nil))
+	assert.Equal(t, ErrNilCallback, s.OnUpdate(nil, 1))
+}
+
+func TestNewMigrateFuncScheme(t *testing.T) {
+	var from []int
+	s, err := NewMigrateFuncScheme(3, "fake", func(_ *sql.DB, fromVersion int) error {
+		from = append(from, fromVersion)
+		return nil
+	})
+	assert.Nil(t, err, "NewMigrateFuncScheme must not return error")
+	assert.Equal(t, SchemeVersion(3), s.Version())
+	assert.Equal(t, "fake", s.VersionStrategy())
+
+	assert.Nil(t, s.OnCreate(nil))
+	assert.Nil(t, s.OnUpdate(nil, 2))
+	assert.Equal(t, []int{0, 2}, from, "Create must migrate from version zero")
+
+	_, err = NewMigrateFuncScheme(1, "fake", nil)
+	assert.Equal(t, ErrNilCallback, err)
+}
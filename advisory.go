@@ -0,0 +1,252 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMigrationLocked is returned by strategies created by
+// NewAdvisoryLockStrategy when another session holds the migration lock
+var ErrMigrationLocked = errors.New("versioned db: migration locked by another session")
+
+// advisoryLockRetry is the interval between lock attempts while waiting
+// for AdvisoryLockTimeout
+const advisoryLockRetry = 50 * time.Millisecond
+
+// AdvisoryLockOption configures a strategy created by
+// NewAdvisoryLockStrategy
+type AdvisoryLockOption func(*advisoryLockStrategy)
+
+// AdvisoryLockTimeout makes the strategy wait up to d for the lock to
+// be released by another session before returning ErrMigrationLocked.
+// By default the lock is tried once
+func AdvisoryLockTimeout(d time.Duration) AdvisoryLockOption {
+	return func(s *advisoryLockStrategy) {
+		s.timeout = d
+	}
+}
+
+// NewAdvisoryLockStrategy wraps inner so only one session at a time
+// migrates a database. Version and SetVersion take the PostgreSQL
+// advisory lock lockID with pg_try_advisory_lock before delegating to
+// inner, and release it with pg_advisory_unlock when they return.
+// Within a migration, such as one run by PersistScheme, the lock is
+// instead taken once and held until the migration returns, whatever
+// strategies wrap this one.
+//
+// Advisory locks belong to a session, so a connection of db is set
+// aside for the lock while it is held.
+//
+// The returned strategy implements TxStrategy when inner does. Outside
+// of a migration holding the lock, VersionTx and SetVersionTx take the
+// lock with pg_try_advisory_xact_lock, released when the transaction
+// ends.
+func NewAdvisoryLockStrategy(inner Strategy, lockID int64, opts ...AdvisoryLockOption) Strategy {
+	s := &advisoryLockStrategy{inner: inner, lockID: lockID, held: make(map[advisoryHolder]*sql.Conn)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if inner, ok := inner.(TxStrategy); ok {
+		return &advisoryLockTxStrategy{advisoryLockStrategy: s, txInner: inner}
+	}
+	return s
+}
+
+type advisoryLockStrategy struct {
+	inner   Strategy
+	lockID  int64
+	timeout time.Duration
+
+	mu   sync.Mutex
+	held map[advisoryHolder]*sql.Conn
+}
+
+// advisoryLockTxStrategy is the advisoryLockStrategy of a TxStrategy
+type advisoryLockTxStrategy struct {
+	*advisoryLockStrategy
+	txInner TxStrategy
+}
+
+// advisoryHolder identifies the migration holding the lock of a
+// database
+type advisoryHolder struct {
+	migration *migration
+	db        *sql.DB
+}
+
+// migration is carried by the context of a migration run, so the
+// strategies holding resources for its duration have them released
+// when it returns, whichever wrappers they are behind
+type migration struct {
+	mu       sync.Mutex
+	releases []func()
+}
+
+type migrationKey struct{}
+
+func migrationFromContext(ctx context.Context) *migration {
+	m, _ := ctx.Value(migrationKey{}).(*migration)
+	return m
+}
+
+// onRelease registers release to be called when the migration returns
+func (m *migration) onRelease(release func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.releases = append(m.releases, release)
+}
+
+// release calls the registered functions, last registered first
+func (m *migration) release() {
+	m.mu.Lock()
+	releases := m.releases
+	m.releases = nil
+	m.mu.Unlock()
+
+	for i := len(releases) - 1; i >= 0; i-- {
+		releases[i]()
+	}
+}
+
+func (s *advisoryLockStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *advisoryLockStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *advisoryLockStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	unlock, err := s.lock(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	return strategyVersion(ctx, s.inner, db)
+}
+
+func (s *advisoryLockStrategy) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	unlock, err := s.lock(ctx, db)
+	if err != nil {
+		return err
+	}
+	err = strategySetVersion(ctx, s.inner, db, version)
+	if unlockErr := unlock(); err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+// lock takes the lock for the call, returning the function releasing
+// it. Within a migration the lock is taken by the first call only and
+// released when the migration returns, the returned function doing
+// nothing
+func (s *advisoryLockStrategy) lock(ctx context.Context, db *sql.DB) (func() error, error) {
+	m := migrationFromContext(ctx)
+	holder := advisoryHolder{m, db}
+	if m != nil {
+		s.mu.Lock()
+		_, ok := s.held[holder]
+		s.mu.Unlock()
+		if ok {
+			return func() error { return nil }, nil
+		}
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.tryLock(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if m == nil {
+		return func() error { return s.unlock(conn) }, nil
+	}
+
+	s.mu.Lock()
+	s.held[holder] = conn
+	s.mu.Unlock()
+	m.onRelease(func() {
+		s.mu.Lock()
+		delete(s.held, holder)
+		s.mu.Unlock()
+		s.unlock(conn)
+	})
+	return func() error { return nil }, nil
+}
+
+func (s *advisoryLockTxStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.versionTx(context.Background(), tx)
+}
+
+func (s *advisoryLockTxStrategy) SetVersionTx(tx *sql.Tx, version int) error {
+	return s.setVersionTx(context.Background(), tx, version)
+}
+
+func (s *advisoryLockTxStrategy) versionTx(ctx context.Context, tx *sql.Tx) (int, error) {
+	if err := s.lockTx(ctx, tx); err != nil {
+		return 0, err
+	}
+	return strategyVersionTx(ctx, s.txInner, tx)
+}
+
+func (s *advisoryLockTxStrategy) setVersionTx(ctx context.Context, tx *sql.Tx, version int) error {
+	if err := s.lockTx(ctx, tx); err != nil {
+		return err
+	}
+	return strategySetVersionTx(ctx, s.txInner, tx, version)
+}
+
+// lockTx takes the lock until tx ends, unless the migration of ctx
+// already holds it on a connection of its own, which would conflict
+// with the lock of tx
+func (s *advisoryLockTxStrategy) lockTx(ctx context.Context, tx *sql.Tx) error {
+	if m := migrationFromContext(ctx); m != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for holder := range s.held {
+			if holder.migration == m {
+				return nil
+			}
+		}
+	}
+	return s.tryLockQuery(ctx, tx, "SELECT pg_try_advisory_xact_lock($1)")
+}
+
+func (s *advisoryLockStrategy) tryLock(ctx context.Context, conn *sql.Conn) error {
+	return s.tryLockQuery(ctx, conn, "SELECT pg_try_advisory_lock($1)")
+}
+
+// tryLockQuery runs query, trying the lock on conn, until it succeeds
+// or AdvisoryLockTimeout is exceeded
+func (s *advisoryLockStrategy) tryLockQuery(ctx context.Context, conn sqlConn, query string) error {
+	deadline := time.Now().Add(s.timeout)
+	for {
+		var locked bool
+		err := conn.QueryRowContext(ctx, query, s.lockID).Scan(&locked)
+		if err != nil || locked {
+			return err
+		}
+		if !time.Now().Before(deadline) {
+			return ErrMigrationLocked
+		}
+
+		select {
+		case <-time.After(advisoryLockRetry):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *advisoryLockStrategy) unlock(conn *sql.Conn) error {
+	defer conn.Close()
+
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", s.lockID)
+	return err
+}
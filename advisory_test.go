@@ -0,0 +1,242 @@
+package version
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestAdvisoryLockStrategyLocksEachCall(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	s := NewAdvisoryLockStrategy(strategy, 42)
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	for i := 0; i < 2; i++ {
+		expectAdvisoryLock(42)
+		expectAdvisoryUnlock(42)
+	}
+
+	v, err := s.Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 1, v)
+	assert.Nil(t, s.SetVersion(db, 2), "SetVersion must not return error")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Outside a migration the lock must be released by every call. Err %q", err)
+	}
+}
+
+func TestAdvisoryLockStrategyMigrationsExcludeEachOther(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	s := NewAdvisoryLockStrategy(strategy, 42)
+	strategy.On("Version", db).Return(1, nil)
+
+	first, endFirst := newOptions(nil).migrationContext(context.Background())
+	second, endSecond := newOptions(nil).migrationContext(context.Background())
+	defer endSecond()
+
+	expectAdvisoryLock(42)
+	dbMock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	expectAdvisoryUnlock(42)
+
+	_, err := strategyVersion(first, s, db)
+	assert.Nil(t, err, "The first migration must take the lock")
+	_, err = strategyVersion(first, s, db)
+	assert.Nil(t, err, "The lock must be reentrant within a migration")
+	_, err = strategyVersion(second, s, db)
+	assert.Equal(t, ErrMigrationLocked, err, "Another migration through the same pool must not take the lock")
+	endFirst()
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestAdvisoryLockStrategyLocked(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	dbMock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	_, err := NewAdvisoryLockStrategy(strategy, 42, AdvisoryLockTimeout(time.Millisecond)).Version(db)
+	assert.Equal(t, ErrMigrationLocked, err)
+	strategy.AssertNotCalled(t, "Version", db)
+}
+
+func TestAdvisoryLockStrategyReleasedWhenUpToDate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("locked", NewAdvisoryLockStrategy(strategy, 7))
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("locked")
+
+	dbMock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	dbMock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("The lock must be released. Err %q", err)
+	}
+}
+
+func TestAdvisoryLockStrategyHeldAcrossSteps(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("locked", NewAdvisoryLockStrategy(strategy, 7))
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil).
+		On("SetVersion", db, 3).Return(nil)
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("locked").
+		On("OnUpdate", db, 1).Return(nil).
+		On("OnUpdate", db, 2).Return(nil)
+
+	dbMock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+	dbMock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("The lock must be released after commit. Err %q", err)
+	}
+}
+
+func TestAdvisoryLockStrategyReleasedThroughWrappers(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("locked", NewTimeoutStrategy(NewMonotonicStrategy(NewAdvisoryLockStrategy(strategy, 7)), 0, 0))
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("locked")
+
+	expectAdvisoryLock(7)
+	expectAdvisoryUnlock(7)
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	expectAdvisoryLock(7)
+	expectAdvisoryUnlock(7)
+	_, err = CurrentVersion(db, "locked")
+	assert.Nil(t, err, "CurrentVersion must not return error")
+
+	expectAdvisoryLock(7)
+	expectAdvisoryUnlock(7)
+	assert.Equal(t, ErrAlreadyBaselined, BaselineScheme(db, scheme))
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("The lock must be released. Err %q", err)
+	}
+}
+
+func TestAdvisoryLockStrategyTx(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, ok := NewAdvisoryLockStrategy(strategy, 7).(TxStrategy)
+	assert.False(t, ok, "TxStrategy is not implemented by the wrapped strategy")
+	s, ok := NewAdvisoryLockStrategy(NewTableStrategy(""), 7).(TxStrategy)
+	assert.True(t, ok, "TxStrategy of the wrapped strategy must be forwarded")
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	dbMock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	v, err := s.VersionTx(tx)
+	assert.Nil(t, err, "VersionTx must not return error")
+	assert.Equal(t, 2, v)
+	assert.Equal(t, ErrMigrationLocked, s.SetVersionTx(tx, 3))
+	assert.Nil(t, tx.Rollback())
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestAdvisoryLockStrategyTxWithinMigration(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("locked", NewAdvisoryLockStrategy(NewTableStrategy(""), 7))
+
+	expectAdvisoryLock(7)
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+	expectAdvisoryUnlock(7)
+
+	_, err := PersistScheme(db, NewSQLScheme(2, "locked", "CREATE TABLE users (id int)", map[int]string{
+		1: "ALTER TABLE users ADD email text",
+	}))
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("The session lock must be held by the migration, not taken again in its transaction. Err %q", err)
+	}
+}
+
+func expectAdvisoryLock(lockID int64) {
+	dbMock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(lockID).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+}
+
+func expectAdvisoryUnlock(lockID int64) {
+	dbMock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(lockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
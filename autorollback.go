@@ -0,0 +1,48 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WithAutoRollback makes PersistScheme undo the updates already
+// recorded when a later step of the migration fails. The steps run
+// within the migration transaction are undone by its rollback. The
+// others, run on db, are migrated back down in reverse order by the
+// OnDowngrade of schemes implementing SchemeWithDowngrade, each
+// followed by the record of its previous version. An error of
+// OnDowngrade is returned wrapped along the migration error
+func WithAutoRollback() Option {
+	return func(o *options) {
+		o.autoRollback = true
+	}
+}
+
+// rollbackSteps migrates db back down from the steps done, last first,
+// after the migration failed with err. It returns err, wrapped along
+// the error of the first step failing to be undone
+func (o *options) rollbackSteps(dbCtx context.Context, db *sql.DB, done []migrationStep, err error) error {
+	if !o.autoRollback || err == nil {
+		return err
+	}
+	for i := len(done) - 1; i >= 0; i-- {
+		step := done[i]
+		if step.forced || step.inTx() {
+			continue
+		}
+		s, ok := asDowngradeScheme(step.scheme)
+		if !ok {
+			return err
+		}
+		if downErr := s.OnDowngrade(db, step.from); downErr != nil {
+			return fmt.Errorf("%w: rolling back to version %d: %w", err, step.from, downErr)
+		}
+		ctx := withForcedVersion(withScheme(dbCtx, step.scheme))
+		if setErr := strategySetVersion(ctx, step.strategy, db, step.from); setErr != nil {
+			return fmt.Errorf("%w: rolling back to version %d: %w", err, step.from, setErr)
+		}
+		logf("rolled back to version %d", step.from)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoRollback(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil).Twice().
+		On("SetVersion", db, 3).Return(nil).Once().
+		On("SetVersion", db, 1).Return(nil).Once()
+
+	dbMock.ExpectBegin()
+	downgrading.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).
+		On("OnUpdate", db, 2).Return(nil).
+		On("OnUpdate", db, 3).Return(someError).
+		On("OnDowngrade", db, 2).Return(nil).Once().
+		On("OnDowngrade", db, 1).Return(nil).Once()
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, downgrading, WithAutoRollback())
+	assert.True(t, errors.Is(err, someError), "Step error must be passed out")
+
+	strategy.AssertExpectations(t)
+	downgrading.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestAutoRollbackDowngradeError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+	downgradeError := errors.New("downgrade failed")
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	downgrading.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).
+		On("OnUpdate", db, 2).Return(someError).
+		On("OnDowngrade", db, 1).Return(downgradeError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, downgrading, WithAutoRollback())
+	assert.True(t, errors.Is(err, someError), "Step error must be passed out")
+	assert.True(t, errors.Is(err, downgradeError), "OnDowngrade error must be wrapped along the step error")
+	strategy.AssertNotCalled(t, "SetVersion", db, 1)
+}
+
+func TestAutoRollbackNotSet(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	downgrading.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).
+		On("OnUpdate", db, 2).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, downgrading)
+	assert.True(t, errors.Is(err, someError), "Step error must be passed out")
+	downgrading.AssertNotCalled(t, "OnDowngrade", db, 1)
+}
@@ -0,0 +1,64 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrEmptyBackendName is returned by BackendStrategy for an empty name
+var ErrEmptyBackendName = errors.New("versioned db: backend name is empty")
+
+// Backend stores scheme versions outside of SQL databases, such as in
+// a key value store or a shared file. name is the one given to
+// BackendStrategy
+type Backend interface {
+	ReadVersion(ctx context.Context, name string) (int, error)
+	WriteVersion(ctx context.Context, name string, version int) error
+}
+
+// BackendStrategy adapts b to a Strategy. The *sql.DB passed to the
+// strategy is ignored and versions are read from and written to b
+// under name, which must be unique among the strategies sharing b.
+// It returns ErrEmptyBackendName when name is empty
+func BackendStrategy(name string, b Backend) (Strategy, error) {
+	if name == "" {
+		return nil, ErrEmptyBackendName
+	}
+	return &backendStrategy{backend: b, name: name}, nil
+}
+
+type backendStrategy struct {
+	backend   Backend
+	name      string
+	namespace string
+}
+
+func (s *backendStrategy) withNamespace(namespace string) Strategy {
+	return &backendStrategy{backend: s.backend, name: s.name, namespace: namespace}
+}
+
+// key is the name versions are stored under, prefixed by the namespace
+// when one is set
+func (s *backendStrategy) key() string {
+	if s.namespace == "" {
+		return s.name
+	}
+	return s.namespace + "/" + s.name
+}
+
+func (s *backendStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *backendStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *backendStrategy) VersionContext(ctx context.Context, _ *sql.DB) (int, error) {
+	return s.backend.ReadVersion(ctx, s.key())
+}
+
+func (s *backendStrategy) SetVersionContext(ctx context.Context, _ *sql.DB, version int) error {
+	return s.backend.WriteVersion(ctx, s.key(), version)
+}
@@ -0,0 +1,71 @@
+package version
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBackendStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	backend := new(backendMock)
+	kv, err := BackendStrategy("orders", backend)
+	assert.Nil(t, err, "BackendStrategy must not return error")
+	Register("kv", NewTimeoutStrategy(kv, time.Second, time.Second))
+
+	backend.
+		On("ReadVersion", mock.Anything, "orders").Return(0, nil).
+		On("WriteVersion", mock.Anything, "orders", 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("kv").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err = PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error on create")
+
+	backend.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+}
+
+func TestBackendStrategyReadError(t *testing.T) {
+	backend := new(backendMock)
+	backend.On("ReadVersion", mock.Anything, "orders").Return(0, someError)
+
+	kv, err := BackendStrategy("orders", backend)
+	assert.Nil(t, err, "BackendStrategy must not return error")
+	_, err = kv.Version(nil)
+	assert.Equal(t, someError, err, "Backend error must be passed out")
+	backend.AssertExpectations(t)
+}
+
+func TestBackendStrategyEmptyName(t *testing.T) {
+	kv, err := BackendStrategy("", new(backendMock))
+	assert.Equal(t, ErrEmptyBackendName, err)
+	assert.Nil(t, kv)
+}
+
+/////////////////////////////////////////////////////
+// Stubs
+/////////////////////////////////////////////////////
+
+type backendMock struct {
+	mock.Mock
+}
+
+func (b *backendMock) ReadVersion(ctx context.Context, name string) (int, error) {
+	args := b.Called(ctx, name)
+	return args.Int(0), args.Error(1)
+}
+
+func (b *backendMock) WriteVersion(ctx context.Context, name string, version int) error {
+	return b.Called(ctx, name, version).Error(0)
+}
@@ -0,0 +1,53 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrAlreadyBaselined is returned by BaselineScheme when the database
+// already records a version
+var ErrAlreadyBaselined = errors.New("versioned db: database already has a version")
+
+// BaselineScheme records the version of scheme on db without running
+// its OnCreate or OnUpdate, for databases whose schema was applied
+// before adopting this package. Databases already recording a version
+// are left untouched and ErrAlreadyBaselined is returned
+func BaselineScheme(db *sql.DB, scheme Scheme) error {
+	return DefaultRegistry.BaselineScheme(db, scheme)
+}
+
+// BaselineScheme is like the package level BaselineScheme but resolves
+// the strategy of scheme in r
+func (r *Registry) BaselineScheme(db *sql.DB, scheme Scheme) error {
+	strategy, version, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return err
+	}
+
+	// Strategies such as NewAdvisoryLockStrategy hold their lock from
+	// the version read to the version record
+	ctx, end := newOptions(nil).migrationContext(context.Background())
+	defer end()
+
+	dbVersion, err := strategyVersion(ctx, strategy, db)
+	if err != nil {
+		return err
+	}
+	if dbVersion != 0 {
+		return ErrAlreadyBaselined
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = strategySetVersion(ctx, strategy, db, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	logf("database baselined at version %d", version)
+	return tx.Commit()
+}
@@ -0,0 +1,73 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaselineScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 3).Return(nil)
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake")
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := BaselineScheme(db, scheme)
+	assert.Nil(t, err, "BaselineScheme must not return error")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertNotCalled(t, "OnCreate", db)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestBaselineSchemeAlreadyBaselined(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(2, nil)
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake")
+
+	err := BaselineScheme(db, scheme)
+	assert.Equal(t, ErrAlreadyBaselined, err)
+	strategy.AssertNotCalled(t, "SetVersion", db, 3)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No transaction must be opened. Err %q", err)
+	}
+}
+
+func TestBaselineSchemeSetVersionError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 3).Return(someError)
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake")
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	assert.Equal(t, someError, BaselineScheme(db, scheme))
+
+	err := dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
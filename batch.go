@@ -0,0 +1,49 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PersistSchemes migrates db to every scheme of schemes in a single
+// transaction: either all of them are persisted or, on the first
+// failing step, none is. Schemes are migrated in the order given, each
+// as PersistScheme would, and may use different strategies. For the
+// batch to be atomic every step runs within that transaction, so the
+// schemes to migrate must implement TxScheme and their strategies
+// TxStrategy; ErrTxNotSupported is returned otherwise, before any
+// scheme is migrated
+func PersistSchemes(db *sql.DB, schemes ...Scheme) error {
+	return DefaultRegistry.PersistSchemes(db, schemes...)
+}
+
+// PersistSchemes is like the package level PersistSchemes but resolves
+// the strategies of schemes in r
+func (r *Registry) PersistSchemes(db *sql.DB, schemes ...Scheme) error {
+	var (
+		o     = newOptions(nil)
+		steps []migrationStep
+	)
+	o.txOnly = true
+
+	ctx, cancel := o.migrationContext(context.Background())
+	defer cancel()
+
+	for _, scheme := range schemes {
+		strategy, version, err := r.resolveScheme(db, scheme)
+		if err != nil {
+			return err
+		}
+
+		plan, err := planMigration(ctx, strategy, db, version, scheme, o)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, plan.steps...)
+	}
+
+	if len(steps) == 0 {
+		return nil
+	}
+	return applySteps(ctx, ctx, db, steps, o, migrationHooks{})
+}
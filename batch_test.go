@@ -0,0 +1,126 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestPersistSchemesSingleTransaction(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("users", NewTableStrategy("users_version"))
+	Register("orders", NewTableStrategy("orders_version"))
+	users := NewSQLScheme(1, "users", "CREATE TABLE users (id int)", nil)
+	orders := NewSQLScheme(2, "orders", "", map[int]string{1: "ALTER TABLE orders ADD total int"})
+
+	expectTableLookup("users_version", false)
+	expectTableLookup("orders_version", true)
+	dbMock.ExpectQuery("SELECT version FROM orders_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersionTables("users_version")
+	dbMock.ExpectQuery("SELECT version FROM users_version").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO users_version").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO users_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec("ALTER TABLE orders ADD total").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("orders_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM orders_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE orders_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO orders_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	err := PersistSchemes(db, users, orders)
+	assert.Nil(t, err, "PersistSchemes must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemesRollsBackBatch(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("users", NewTableStrategy("users_version"))
+	Register("orders", NewTableStrategy("orders_version"))
+	users := NewSQLScheme(1, "users", "CREATE TABLE users (id int)", nil)
+	orders := NewSQLScheme(1, "orders", "CREATE TABLE orders (id int)", nil)
+
+	expectTableLookup("users_version", false)
+	expectTableLookup("orders_version", false)
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersionTables("users_version")
+	dbMock.ExpectQuery("SELECT version FROM users_version").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO users_version").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO users_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec("CREATE TABLE orders").WillReturnError(someError)
+	// Neither the users table nor its version may be committed
+	dbMock.ExpectRollback()
+
+	err := PersistSchemes(db, users, orders)
+	assert.True(t, errors.Is(err, someError), "The failing scheme error must be passed out")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("The whole batch must be rolled back. Err %q", err)
+	}
+}
+
+func TestPersistSchemesTxNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("users", NewTableStrategy("users_version"))
+	users := NewSQLScheme(1, "users", "CREATE TABLE users (id int)", nil)
+	strategy.On("Version", db).Return(0, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	expectTableLookup("users_version", false)
+
+	err := PersistSchemes(db, users, scheme)
+	assert.Equal(t, ErrTxNotSupported, err)
+	scheme.AssertNotCalled(t, "OnCreate", db)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No scheme must be migrated. Err %q", err)
+	}
+}
+
+func TestPersistSchemesValidatesBeforeMigrating(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("not_registered")
+
+	err := PersistSchemes(db, scheme)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Unknown strategy must be reported")
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No transaction must be opened. Err %q", err)
+	}
+}
+
+func TestPersistSchemesUpToDate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	assert.Nil(t, PersistSchemes(db, scheme), "Up to date schemes need no transaction support")
+}
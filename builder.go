@@ -0,0 +1,111 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyStrategyName is returned by SchemeBuilder.Build when no
+// strategy name was set
+var ErrEmptyStrategyName = errors.New("versioned db: strategy name is empty")
+
+// SchemeBuilder builds a Scheme from its version, strategy name and
+// callbacks, sparing the definition of a type:
+//
+//	scheme, err := version.NewSchemeBuilder().
+//		WithVersion(2).
+//		WithStrategy("table").
+//		OnCreate(create).
+//		OnUpdate(update).
+//		Build()
+//
+// Callbacks left unset do nothing
+type SchemeBuilder struct {
+	version     int
+	strategy    string
+	description string
+	onCreate    func(*sql.DB) error
+	onUpdate    func(*sql.DB, int) error
+}
+
+// NewSchemeBuilder returns an empty SchemeBuilder
+func NewSchemeBuilder() *SchemeBuilder {
+	return new(SchemeBuilder)
+}
+
+// WithVersion sets the version of the scheme
+func (b *SchemeBuilder) WithVersion(version int) *SchemeBuilder {
+	b.version = version
+	return b
+}
+
+// WithStrategy sets the name of the strategy of the scheme
+func (b *SchemeBuilder) WithStrategy(name string) *SchemeBuilder {
+	b.strategy = name
+	return b
+}
+
+// WithDescription sets the description the scheme reports as a
+// SchemeDescriber
+func (b *SchemeBuilder) WithDescription(description string) *SchemeBuilder {
+	b.description = description
+	return b
+}
+
+// OnCreate sets the callback creating the scheme
+func (b *SchemeBuilder) OnCreate(fn func(*sql.DB) error) *SchemeBuilder {
+	b.onCreate = fn
+	return b
+}
+
+// OnUpdate sets the callback updating the scheme from an old version
+func (b *SchemeBuilder) OnUpdate(fn func(*sql.DB, int) error) *SchemeBuilder {
+	b.onUpdate = fn
+	return b
+}
+
+// Build returns the scheme. It fails if the version is below one or if
+// the strategy name is empty, the returned error wrapping
+// ErrVersionBelowOne or ErrEmptyStrategyName. Later changes to b do not
+// affect the returned scheme
+func (b *SchemeBuilder) Build() (Scheme, error) {
+	if b.version < 1 {
+		return nil, fmt.Errorf("%w, got %d", ErrVersionBelowOne, b.version)
+	}
+	if b.strategy == "" {
+		return nil, ErrEmptyStrategyName
+	}
+	return &builtScheme{*b}, nil
+}
+
+// builtScheme is the Scheme returned by SchemeBuilder.Build
+type builtScheme struct {
+	b SchemeBuilder
+}
+
+func (s *builtScheme) Version() SchemeVersion {
+	return SchemeVersion(s.b.version)
+}
+
+func (s *builtScheme) VersionStrategy() string {
+	return s.b.strategy
+}
+
+func (s *builtScheme) Description() string {
+	return s.b.description
+}
+
+func (s *builtScheme) OnCreate(db *sql.DB) error {
+	if s.b.onCreate == nil {
+		return nil
+	}
+	return s.b.onCreate(db)
+}
+
+func (s *builtScheme) OnUpdate(db *sql.DB, oldVersion int) error {
+	if s.b.onUpdate == nil {
+		return nil
+	}
+	return s.b.onUpdate(db, oldVersion)
+}
@@ -0,0 +1,64 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemeBuilder(t *testing.T) {
+	var created, updatedFrom int
+
+	b := NewSchemeBuilder().
+		WithVersion(2).
+		WithStrategy("fake").
+		OnCreate(func(*sql.DB) error { created++; return nil }).
+		OnUpdate(func(_ *sql.DB, oldVersion int) error { updatedFrom = oldVersion; return someError })
+
+	s, err := b.Build()
+	assert.Nil(t, err, "Build must not return error")
+	b.WithVersion(3)
+
+	assert.Equal(t, SchemeVersion(2), s.Version(), "Built scheme must not follow the builder")
+	assert.Equal(t, "fake", s.VersionStrategy())
+	assert.Nil(t, s.OnCreate(nil))
+	assert.Equal(t, 1, created)
+	assert.Equal(t, someError, s.OnUpdate(nil, 1))
+	assert.Equal(t, 1, updatedFrom)
+}
+
+func TestSchemeBuilderDescription(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(1, nil)
+
+	s, err := NewSchemeBuilder().WithVersion(1).WithStrategy("fake").WithDescription("users table").Build()
+	assert.Nil(t, err)
+
+	res, err := PersistScheme(db, s)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, "users table", res.Description, "Description must be reported in the result")
+}
+
+func TestSchemeBuilderUnsetCallbacks(t *testing.T) {
+	s, err := NewSchemeBuilder().WithVersion(1).WithStrategy("fake").Build()
+	assert.Nil(t, err)
+	assert.Nil(t, s.OnCreate(nil), "Unset OnCreate must do nothing")
+	assert.Nil(t, s.OnUpdate(nil, 1), "Unset OnUpdate must do nothing")
+}
+
+func TestSchemeBuilderMissingVersion(t *testing.T) {
+	_, err := NewSchemeBuilder().WithStrategy("fake").Build()
+	assert.True(t, errors.Is(err, ErrVersionBelowOne), "Unset version must be rejected")
+
+	_, err = NewSchemeBuilder().WithVersion(-1).WithStrategy("fake").Build()
+	assert.True(t, errors.Is(err, ErrVersionBelowOne), "Version below one must be rejected")
+}
+
+func TestSchemeBuilderMissingStrategy(t *testing.T) {
+	_, err := NewSchemeBuilder().WithVersion(1).Build()
+	assert.Equal(t, ErrEmptyStrategyName, err)
+}
@@ -0,0 +1,44 @@
+package version
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SchemeChain is a list of schemes migrated one after the other, each
+// in its own transaction, as opposed to PersistSchemes
+type SchemeChain []Scheme
+
+// ChainError is returned by PersistSchemeChain when the scheme at Index
+// fails. The schemes before it stay migrated
+type ChainError struct {
+	Index int
+	Err   error
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("versioned db: scheme %d of the chain failed: %v", e.Index, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// PersistSchemeChain migrates db to every scheme of chain in order, each
+// by its own PersistScheme call with opts. The first failure stops the
+// chain and is returned as a *ChainError, earlier schemes are not rolled
+// back
+func PersistSchemeChain(db *sql.DB, chain SchemeChain, opts ...Option) error {
+	return DefaultRegistry.PersistSchemeChain(db, chain, opts...)
+}
+
+// PersistSchemeChain is like the package level PersistSchemeChain but
+// resolves the strategies of chain in r
+func (r *Registry) PersistSchemeChain(db *sql.DB, chain SchemeChain, opts ...Option) error {
+	for i, scheme := range chain {
+		if _, err := r.PersistScheme(db, scheme, opts...); err != nil {
+			return &ChainError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
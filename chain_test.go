@@ -0,0 +1,45 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistSchemeChain(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	first, failing, last := new(schemeMock), new(schemeMock), new(schemeMock)
+	for _, s := range []*schemeMock{first, failing, last} {
+		s.
+			On("Version").Return(1).
+			On("VersionStrategy").Return("fake")
+	}
+	first.On("OnCreate", db).Return(nil)
+	failing.On("OnCreate", db).Return(someError)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil).Once()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	err := PersistSchemeChain(db, SchemeChain{first, failing, last})
+	var chainErr *ChainError
+	if assert.True(t, errors.As(err, &chainErr), "ChainError must be returned") {
+		assert.Equal(t, 1, chainErr.Index, "Failing index must be reported")
+	}
+	assert.True(t, errors.Is(err, someError), "Scheme error must be wrapped")
+	last.AssertNotCalled(t, "OnCreate", db)
+
+	first.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Earlier schemes must stay committed. Err %q", err)
+	}
+}
@@ -0,0 +1,168 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrChecksumMismatch is returned by strategies created by
+// NewChecksumStrategy when the migration scripts of a scheme no longer
+// match the checksum recorded when its version was set
+var ErrChecksumMismatch = errors.New("versioned db: migration checksum mismatch")
+
+// ErrChecksumNotSupported is returned by wrappers of schemes not
+// implementing ChecksumScheme when asked for a checksum
+var ErrChecksumNotSupported = errors.New("versioned db: scheme does not support checksums")
+
+// ChecksumStore records the checksum of each version set on a database
+type ChecksumStore interface {
+	Get(version int) (checksum string, ok bool, err error)
+	Set(version int, checksum string) error
+}
+
+// ChecksumScheme is implemented by schemes able to checksum the
+// migration scripts leading to version. The checksum of a version must
+// not change once that version is released. The schemes returned by
+// LoadSQLScheme, LoadSQLSchemeFromFS and NewSQLScheme implement it
+type ChecksumScheme interface {
+	Scheme
+	Checksum(version int) (string, error)
+}
+
+// NewChecksumStrategy wraps inner to detect migration scripts modified
+// after being applied. When PersistScheme sets a version, the checksum
+// of the scheme at that version is recorded in store; when it later
+// reads the version, the checksum is computed again and
+// ErrChecksumMismatch is returned if it differs. Schemes not
+// implementing ChecksumScheme, versions without recorded checksum and
+// databases ahead of the scheme are not checked. The returned strategy
+// implements TxStrategy when inner does
+func NewChecksumStrategy(inner Strategy, store ChecksumStore) Strategy {
+	s := &checksumStrategy{inner: inner, store: store}
+	if inner, ok := inner.(TxStrategy); ok {
+		return &checksumTxStrategy{checksumStrategy: s, txInner: inner}
+	}
+	return s
+}
+
+type checksumStrategy struct {
+	inner Strategy
+	store ChecksumStore
+}
+
+// checksumTxStrategy is the checksumStrategy of a TxStrategy
+type checksumTxStrategy struct {
+	*checksumStrategy
+	txInner TxStrategy
+}
+
+type schemeKey struct{}
+
+// withScheme returns ctx carrying the scheme being migrated, for the
+// strategies that need it
+func withScheme(ctx context.Context, scheme Scheme) context.Context {
+	return context.WithValue(ctx, schemeKey{}, scheme)
+}
+
+func checksumSchemeFromContext(ctx context.Context) (ChecksumScheme, bool) {
+	scheme, ok := ctx.Value(schemeKey{}).(Scheme)
+	if !ok {
+		return nil, false
+	}
+	return asChecksumScheme(scheme)
+}
+
+// asChecksumScheme asserts scheme to ChecksumScheme, unless it is a
+// wrapper around a scheme not implementing it
+func asChecksumScheme(scheme Scheme) (ChecksumScheme, bool) {
+	s, ok := scheme.(ChecksumScheme)
+	return s, ok && wrappedSchemeSupports(scheme, func(scheme Scheme) bool {
+		_, ok := scheme.(ChecksumScheme)
+		return ok
+	})
+}
+
+func (s *checksumStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *checksumStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *checksumStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	version, err := strategyVersion(ctx, s.inner, db)
+	if err != nil {
+		return 0, err
+	}
+	return s.verify(ctx, version)
+}
+
+func (s *checksumStrategy) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	return s.record(ctx, version, func() error {
+		return strategySetVersion(ctx, s.inner, db, version)
+	})
+}
+
+// verify checks the checksum recorded for version, read by the caller,
+// against the one of the scheme carried by ctx
+func (s *checksumStrategy) verify(ctx context.Context, version int) (int, error) {
+	scheme, ok := checksumSchemeFromContext(ctx)
+	if version == 0 || !ok || version > scheme.Version().Int() {
+		return version, nil
+	}
+
+	expected, ok, err := s.store.Get(version)
+	if err != nil || !ok {
+		return version, err
+	}
+	actual, err := scheme.Checksum(version)
+	if err != nil {
+		return 0, err
+	}
+	if actual != expected {
+		return 0, fmt.Errorf("%w at version %d, recorded %s, got %s", ErrChecksumMismatch, version, expected, actual)
+	}
+	return version, nil
+}
+
+// record calls setVersion and records the checksum of version for the
+// scheme carried by ctx
+func (s *checksumStrategy) record(ctx context.Context, version int, setVersion func() error) error {
+	scheme, ok := checksumSchemeFromContext(ctx)
+	if !ok {
+		return setVersion()
+	}
+	checksum, err := scheme.Checksum(version)
+	if err != nil {
+		return err
+	}
+	if err = setVersion(); err != nil {
+		return err
+	}
+	return s.store.Set(version, checksum)
+}
+
+func (s *checksumTxStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.versionTx(context.Background(), tx)
+}
+
+func (s *checksumTxStrategy) SetVersionTx(tx *sql.Tx, version int) error {
+	return s.setVersionTx(context.Background(), tx, version)
+}
+
+func (s *checksumTxStrategy) versionTx(ctx context.Context, tx *sql.Tx) (int, error) {
+	version, err := strategyVersionTx(ctx, s.txInner, tx)
+	if err != nil {
+		return 0, err
+	}
+	return s.verify(ctx, version)
+}
+
+func (s *checksumTxStrategy) setVersionTx(ctx context.Context, tx *sql.Tx, version int) error {
+	return s.record(ctx, version, func() error {
+		return strategySetVersionTx(ctx, s.txInner, tx, version)
+	})
+}
@@ -0,0 +1,152 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestChecksumStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	store := make(checksumStoreMock)
+	Register("checksum", NewChecksumStrategy(strategy, store))
+
+	strategy.
+		On("Version", db).Return(0, nil).Once().
+		On("SetVersion", db, 2).Return(nil).
+		On("Version", db).Return(2, nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, NewSQLScheme(2, "checksum", "CREATE TABLE users (id int)", map[int]string{
+		1: "ALTER TABLE users ADD email text",
+	}))
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Len(t, store, 1, "Checksum must be recorded on SetVersion")
+
+	_, err = PersistScheme(db, NewSQLScheme(2, "checksum", "CREATE TABLE users (id int)", map[int]string{
+		1: "ALTER TABLE users ADD email text",
+	}))
+	assert.Nil(t, err, "Untouched scripts must pass the check")
+
+	_, err = PersistScheme(db, NewSQLScheme(2, "checksum", "CREATE TABLE users (id int)", map[int]string{
+		1: "ALTER TABLE users ADD mail text",
+	}))
+	assert.True(t, errors.Is(err, ErrChecksumMismatch), "Modified script must be reported")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestChecksumStrategyTx(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	store := make(checksumStoreMock)
+	_, ok := NewChecksumStrategy(strategy, store).(TxStrategy)
+	assert.False(t, ok, "TxStrategy is not implemented by the wrapped strategy")
+	Register("checksum", NewChecksumStrategy(NewTableStrategy(""), store))
+
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	_, err = PersistSchemeTx(tx, NewSQLScheme(2, "checksum", "CREATE TABLE users (id int)", map[int]string{
+		1: "ALTER TABLE users ADD email text",
+	}))
+	assert.Nil(t, err, "PersistSchemeTx must not return error")
+	assert.Nil(t, tx.Commit())
+	assert.Len(t, store, 1, "Checksum must be recorded on SetVersionTx")
+
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectRollback()
+
+	tx, err = db.Begin()
+	assert.Nil(t, err)
+	_, err = PersistSchemeTx(tx, NewSQLScheme(2, "checksum", "CREATE TABLE users (id int)", map[int]string{
+		1: "ALTER TABLE users ADD mail text",
+	}))
+	assert.True(t, errors.Is(err, ErrChecksumMismatch), "Modified script must be reported by VersionTx")
+	assert.Nil(t, tx.Rollback())
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestChecksumStrategyWithoutScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	store := make(checksumStoreMock)
+	store[3] = "recorded"
+	strategy.On("Version", db).Return(3, nil)
+
+	v, err := NewChecksumStrategy(strategy, store).Version(db)
+	assert.Nil(t, err, "Calls outside PersistScheme must not be checked")
+	assert.Equal(t, 3, v)
+}
+
+func TestChecksumStrategyFailedSetVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	store := make(checksumStoreMock)
+	Register("checksum", NewChecksumStrategy(strategy, store))
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 2).Return(someError)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, NewSQLScheme(2, "checksum", "CREATE TABLE users (id int)", nil))
+	assert.NotNil(t, err, "SetVersion error must be returned")
+	assert.Empty(t, store, "Checksum must not be recorded when SetVersion fails")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+// Stubs
+/////////////////////////////////////////////////////
+
+type checksumStoreMock map[int]string
+
+func (m checksumStoreMock) Get(version int) (string, bool, error) {
+	checksum, ok := m[version]
+	return checksum, ok, nil
+}
+
+func (m checksumStoreMock) Set(version int, checksum string) error {
+	m[version] = checksum
+	return nil
+}
@@ -0,0 +1,164 @@
+// Command mockgen-strategy generates a Strategy test double that only
+// depends on the standard testing package.
+//
+// Usage:
+//
+//	mockgen-strategy -name fake [-type MockStrategy] [-package version] [-o strategy_mock_test.go]
+//
+// The generated type records the expected Version and SetVersion calls
+// in slices, answers calls in the order they were expected and reports
+// missing or unexpected calls from AssertExpectations. It lets the
+// tests of code using the package mock a Strategy without testify/mock;
+// the tests of the package itself keep their testify mocks.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"text/template"
+)
+
+var (
+	strategyName = flag.String("name", "", "name the mocked strategy is registered with")
+	typeName     = flag.String("type", "MockStrategy", "name of the generated type")
+	packageName  = flag.String("package", "version", "package of the generated file")
+	output       = flag.String("o", "", "output file, stdout when empty")
+)
+
+func main() {
+	flag.Parse()
+	if *strategyName == "" {
+		fmt.Fprintln(os.Stderr, "mockgen-strategy: -name is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := generate(*packageName, *typeName, *strategyName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mockgen-strategy:", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err = os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "mockgen-strategy:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(packageName, typeName, strategyName string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := mockTemplate.Execute(&buf, struct {
+		Package, Type, Name string
+	}{packageName, typeName, strategyName})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+var mockTemplate = template.Must(template.New("mock").Parse(`// Code generated by mockgen-strategy; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+// {{.Type}}Name is the name {{.Type}} is meant to be registered with
+const {{.Type}}Name = {{printf "%q" .Name}}
+
+// {{.Type}} is a Strategy test double. Calls are answered in the
+// order they were expected and checked by AssertExpectations
+type {{.Type}} struct {
+	versionCalls    []{{.Type}}VersionCall
+	setVersionCalls []{{.Type}}SetVersionCall
+	unexpected      []string
+}
+
+// {{.Type}}VersionCall is an expected call to Version
+type {{.Type}}VersionCall struct {
+	DB      *sql.DB
+	Version int
+	Err     error
+	called  bool
+}
+
+// {{.Type}}SetVersionCall is an expected call to SetVersion
+type {{.Type}}SetVersionCall struct {
+	DB      *sql.DB
+	Version int
+	Err     error
+	called  bool
+}
+
+// ExpectVersion expects a call to Version with db which will
+// return version and err
+func (m *{{.Type}}) ExpectVersion(db *sql.DB, version int, err error) *{{.Type}} {
+	m.versionCalls = append(m.versionCalls, {{.Type}}VersionCall{DB: db, Version: version, Err: err})
+	return m
+}
+
+// ExpectSetVersion expects a call to SetVersion with db and version
+// which will return err
+func (m *{{.Type}}) ExpectSetVersion(db *sql.DB, version int, err error) *{{.Type}} {
+	m.setVersionCalls = append(m.setVersionCalls, {{.Type}}SetVersionCall{DB: db, Version: version, Err: err})
+	return m
+}
+
+func (m *{{.Type}}) Version(db *sql.DB) (int, error) {
+	for i := range m.versionCalls {
+		call := &m.versionCalls[i]
+		if !call.called && call.DB == db {
+			call.called = true
+			return call.Version, call.Err
+		}
+	}
+	m.unexpected = append(m.unexpected, "Version(db)")
+	return 0, nil
+}
+
+func (m *{{.Type}}) SetVersion(db *sql.DB, version int) error {
+	for i := range m.setVersionCalls {
+		call := &m.setVersionCalls[i]
+		if !call.called && call.DB == db && call.Version == version {
+			call.called = true
+			return call.Err
+		}
+	}
+	m.unexpected = append(m.unexpected, fmt.Sprintf("SetVersion(db, %d)", version))
+	return nil
+}
+
+// AssertExpectations fails t for every expected call that was not
+// made and for every call that was not expected
+func (m *{{.Type}}) AssertExpectations(t *testing.T) bool {
+	t.Helper()
+	ok := true
+	for _, call := range m.versionCalls {
+		if !call.called {
+			t.Errorf("{{.Type}}: expected call Version(db) returning (%d, %v) was not made", call.Version, call.Err)
+			ok = false
+		}
+	}
+	for _, call := range m.setVersionCalls {
+		if !call.called {
+			t.Errorf("{{.Type}}: expected call SetVersion(db, %d) was not made", call.Version)
+			ok = false
+		}
+	}
+	for _, call := range m.unexpected {
+		t.Errorf("{{.Type}}: unexpected call %s", call)
+		ok = false
+	}
+	return ok
+}
+`))
@@ -0,0 +1,116 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+const (
+	defaultTable = "schema_version"
+	// maxRetries bounds the attempts of a SetVersion transaction
+	// aborted by a serialization failure
+	maxRetries = 5
+	retryDelay = 10 * time.Millisecond
+	// serializationFailure is the SQLSTATE CockroachDB returns when a
+	// transaction must be retried
+	serializationFailure = "40001"
+)
+
+// NewCockroachDBStrategy returns a strategy storing the version in the
+// single row of tableName, schema_version when empty, of a CockroachDB
+// cluster.
+//
+// The table is created by its own statement, never inside the
+// transaction writing the version, as CockroachDB releases before 21.1
+// do not support mixing schema changes and writes in a transaction.
+// SetVersion runs in a SERIALIZABLE transaction that is retried when
+// aborted with SQLSTATE 40001.
+//
+// Compatibility:
+//
+//	CockroachDB   Supported  Notes
+//	< 20.2        untested
+//	20.2 - 21.0   yes        schema changes kept out of transactions
+//	>= 21.1       yes
+func NewCockroachDBStrategy(tableName string) version.Strategy {
+	if tableName == "" {
+		tableName = defaultTable
+	}
+	return &cockroachStrategy{table: tableName}
+}
+
+type cockroachStrategy struct {
+	table string
+}
+
+func (s *cockroachStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *cockroachStrategy) SetVersion(db *sql.DB, v int) error {
+	return s.SetVersionContext(context.Background(), db, v)
+}
+
+func (s *cockroachStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	if err := s.createTable(ctx, db); err != nil {
+		return 0, err
+	}
+
+	var v int
+	err := db.QueryRowContext(ctx, "SELECT version FROM "+s.table+" WHERE id").Scan(&v)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return v, err
+}
+
+func (s *cockroachStrategy) SetVersionContext(ctx context.Context, db *sql.DB, v int) error {
+	if err := s.createTable(ctx, db); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.upsert(ctx, db, v)
+		if !isRetryable(err) || attempt == maxRetries {
+			return err
+		}
+
+		backoff := time.NewTimer(retryDelay << uint(attempt-1))
+		select {
+		case <-backoff.C:
+		case <-ctx.Done():
+			backoff.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *cockroachStrategy) upsert(ctx context.Context, db *sql.DB, v int) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "UPSERT INTO "+s.table+" (id, version) VALUES (true, $1)", v); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *cockroachStrategy) createTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+
+		" (id BOOL PRIMARY KEY DEFAULT true CHECK (id), version INT NOT NULL)")
+	return err
+}
+
+// isRetryable tells whether err, or an error it wraps, is a
+// serialization failure. The SQLSTATE is read through the SQLState
+// method of the driver errors, as exposed by pgx and lib/pq
+func isRetryable(err error) bool {
+	var e interface{ SQLState() string }
+	return errors.As(err, &e) && e.SQLState() == serializationFailure
+}
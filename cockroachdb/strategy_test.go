@@ -0,0 +1,137 @@
+package cockroachdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gabriel-araujjo/versioned-database"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pq: restart transaction" }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS versions").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT version FROM versions").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+
+	v, err := NewCockroachDBStrategy("versions").Version(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestVersionEmpty(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	v, err := NewCockroachDBStrategy("").Version(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, v, "Empty table must report version zero")
+}
+
+func TestSetVersionRetriesSerializationFailure(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPSERT INTO schema_version").WithArgs(3).WillReturnError(sqlStateError("40001"))
+	dbMock.ExpectRollback()
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPSERT INTO schema_version").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	assert.Nil(t, NewCockroachDBStrategy("").SetVersion(db, 3), "Serialization failures must be retried")
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSetVersionGivesUpAfterMaxRetries(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < maxRetries; i++ {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("UPSERT INTO schema_version").WithArgs(3).WillReturnError(sqlStateError("40001"))
+		dbMock.ExpectRollback()
+	}
+
+	start := time.Now()
+	err = NewCockroachDBStrategy("").SetVersion(db, 3)
+	assert.Equal(t, sqlStateError("40001"), err, "The last serialization failure must be returned")
+	// The backoffs between the attempts add up to 150ms, a sleep after
+	// the last one would take 160ms more
+	assert.Less(t, time.Since(start), 300*time.Millisecond, "No backoff must follow the last attempt")
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSetVersionContextDoneDuringBackoff(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPSERT INTO schema_version").WithArgs(3).WillReturnError(sqlStateError("40001"))
+	dbMock.ExpectRollback()
+
+	ctx, cancel := context.WithTimeout(context.Background(), retryDelay/2)
+	defer cancel()
+
+	err = NewCockroachDBStrategy("").(version.ContextStrategy).SetVersionContext(ctx, db, 3)
+	assert.Equal(t, context.DeadlineExceeded, err, "The context error must be returned when done during the backoff")
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSetVersionDoesNotRetryOtherErrors(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	someError := errors.New("SomeError")
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPSERT INTO schema_version").WillReturnError(someError)
+	dbMock.ExpectRollback()
+
+	assert.Equal(t, someError, NewCockroachDBStrategy("").SetVersion(db, 3))
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(sqlStateError("40001")))
+	assert.True(t, isRetryable(fmt.Errorf("upsert: %w", sqlStateError("40001"))), "Wrapped driver errors must be recognised")
+	assert.False(t, isRetryable(sqlStateError("23505")))
+	assert.False(t, isRetryable(errors.New("pq: 40001 in the message")), "Messages must not be matched")
+	assert.False(t, isRetryable(nil))
+}
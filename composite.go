@@ -0,0 +1,104 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+)
+
+// NewCompositeScheme returns a scheme of the given version and strategy
+// grouping schemes, so their changes are recorded as a single version.
+// OnCreate and OnUpdate call those of schemes in order, stopping at the
+// first error. When every scheme is a TxScheme, so is the returned
+// scheme and the schemes migrate within the migration transaction.
+// Otherwise they migrate db, so a failing scheme does not undo the
+// changes of the previous ones. The versions and strategies of schemes
+// are ignored. It panics if any of schemes is nil
+func NewCompositeScheme(version int, strategyName string, schemes ...Scheme) Scheme {
+	txSchemes := make([]TxScheme, 0, len(schemes))
+	for _, scheme := range schemes {
+		if scheme == nil {
+			panic("versioned db: NewCompositeScheme sub-scheme is nil")
+		}
+		if s, ok := asTxScheme(scheme); ok {
+			txSchemes = append(txSchemes, s)
+		}
+	}
+	composite := &compositeScheme{version: version, strategy: strategyName, schemes: schemes}
+	if len(txSchemes) < len(schemes) {
+		return composite
+	}
+	return &compositeTxScheme{compositeScheme: composite, txSchemes: txSchemes}
+}
+
+type compositeScheme struct {
+	version  int
+	strategy string
+	schemes  []Scheme
+}
+
+func (s *compositeScheme) Version() SchemeVersion {
+	return SchemeVersion(s.version)
+}
+
+func (s *compositeScheme) VersionStrategy() string {
+	return s.strategy
+}
+
+func (s *compositeScheme) OnCreate(db *sql.DB) error {
+	return s.OnCreateContext(context.Background(), db)
+}
+
+func (s *compositeScheme) OnUpdate(db *sql.DB, oldVersion int) error {
+	return s.OnUpdateContext(context.Background(), db, oldVersion)
+}
+
+func (s *compositeScheme) OnCreateContext(ctx context.Context, db *sql.DB) error {
+	for _, scheme := range s.schemes {
+		if err := schemeCreate(ctx, scheme, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *compositeScheme) OnUpdateContext(ctx context.Context, db *sql.DB, oldVersion int) error {
+	for _, scheme := range s.schemes {
+		if err := schemeUpdate(ctx, scheme, db, oldVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compositeTxScheme is the composite of schemes which all implement
+// TxScheme
+type compositeTxScheme struct {
+	*compositeScheme
+	txSchemes []TxScheme
+}
+
+func (s *compositeTxScheme) OnCreateTx(tx *sql.Tx) error {
+	return s.onCreateTx(context.Background(), tx)
+}
+
+func (s *compositeTxScheme) OnUpdateTx(tx *sql.Tx, oldVersion int) error {
+	return s.onUpdateTx(context.Background(), tx, oldVersion)
+}
+
+func (s *compositeTxScheme) onCreateTx(ctx context.Context, tx *sql.Tx) error {
+	for _, scheme := range s.txSchemes {
+		if err := schemeCreateTx(ctx, scheme, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *compositeTxScheme) onUpdateTx(ctx context.Context, tx *sql.Tx, oldVersion int) error {
+	for _, scheme := range s.txSchemes {
+		if err := schemeUpdateTx(ctx, scheme, tx, oldVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCompositeScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	users, orders := new(schemeMock), new(schemeMock)
+	users.
+		On("OnCreate", db).Return(nil).
+		On("OnUpdate", db, 1).Return(nil)
+	orders.
+		On("OnCreate", db).Return(nil).
+		On("OnUpdate", db, 1).Return(nil)
+
+	composite := NewCompositeScheme(2, "fake", users, orders)
+	assert.Equal(t, SchemeVersion(2), composite.Version())
+	assert.Equal(t, "fake", composite.VersionStrategy())
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, composite)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Nil(t, composite.OnCreate(db))
+
+	users.AssertExpectations(t)
+	orders.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestCompositeSchemeStopsOnError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	users, orders := new(schemeMock), new(schemeMock)
+	users.On("OnCreate", db).Return(someError)
+
+	err := NewCompositeScheme(1, "fake", users, orders).OnCreate(db)
+	assert.True(t, errors.Is(err, someError), "Sub-scheme error must be returned")
+	orders.AssertNotCalled(t, "OnCreate", db)
+}
+
+func TestCompositeSchemeTx(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	composite := NewCompositeScheme(2, "fake",
+		NewSQLScheme(2, "fake", "CREATE TABLE users (id int)", map[int]string{1: "ALTER TABLE users ADD email text"}),
+		NewSQLScheme(2, "fake", "CREATE TABLE orders (id int)", map[int]string{1: "ALTER TABLE orders ADD total int"}))
+	txScheme, ok := asTxScheme(composite)
+	assert.True(t, ok, "A composite of TxSchemes must be a TxScheme")
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("ALTER TABLE orders ADD total").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectCommit()
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	assert.Nil(t, txScheme.OnUpdateTx(tx, 1), "OnUpdateTx must not return error")
+	assert.Nil(t, tx.Commit())
+
+	_, ok = asTxScheme(NewCompositeScheme(2, "fake", NewSQLScheme(2, "fake", "CREATE TABLE users (id int)", nil), new(schemeMock)))
+	assert.False(t, ok, "A composite with a scheme not implementing TxScheme must not be a TxScheme")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestCompositeSchemeNilScheme(t *testing.T) {
+	assert.Panics(t, func() { NewCompositeScheme(1, "fake", new(schemeMock), nil) })
+}
@@ -0,0 +1,52 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ContextStrategy is implemented by strategies able to honour the
+// context of PersistSchemeContext. When a strategy implements it,
+// its context variants are called instead of Version and SetVersion
+type ContextStrategy interface {
+	Strategy
+	VersionContext(ctx context.Context, db *sql.DB) (int, error)
+	SetVersionContext(ctx context.Context, db *sql.DB, version int) error
+}
+
+// ContextScheme is implemented by schemes able to honour the context
+// of PersistSchemeContext. When a scheme implements it, its context
+// variants are called instead of OnCreate and OnUpdate
+type ContextScheme interface {
+	Scheme
+	OnCreateContext(ctx context.Context, db *sql.DB) error
+	OnUpdateContext(ctx context.Context, db *sql.DB, oldVersion int) error
+}
+
+func strategyVersion(ctx context.Context, strategy Strategy, db *sql.DB) (int, error) {
+	if s, ok := strategy.(ContextStrategy); ok {
+		return s.VersionContext(ctx, db)
+	}
+	return strategy.Version(db)
+}
+
+func strategySetVersion(ctx context.Context, strategy Strategy, db *sql.DB, version int) error {
+	if s, ok := strategy.(ContextStrategy); ok {
+		return s.SetVersionContext(ctx, db, version)
+	}
+	return strategy.SetVersion(db, version)
+}
+
+func schemeCreate(ctx context.Context, scheme Scheme, db *sql.DB) error {
+	if s, ok := scheme.(ContextScheme); ok {
+		return s.OnCreateContext(ctx, db)
+	}
+	return scheme.OnCreate(db)
+}
+
+func schemeUpdate(ctx context.Context, scheme Scheme, db *sql.DB, oldVersion int) error {
+	if s, ok := scheme.(ContextScheme); ok {
+		return s.OnUpdateContext(ctx, db, oldVersion)
+	}
+	return scheme.OnUpdate(db, oldVersion)
+}
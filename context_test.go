@@ -0,0 +1,118 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ctxKey struct{}
+
+// fromCtx matches the contexts derived from the one built by the tests
+var fromCtx = mock.MatchedBy(func(ctx context.Context) bool {
+	return ctx.Value(ctxKey{}) == "value"
+})
+
+func TestPersistSchemeContextForwardsContext(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	ctxStrategy := new(contextStrategyMock)
+	ctxScheme := new(contextSchemeMock)
+	Register("ctx", ctxStrategy)
+
+	ctxStrategy.
+		On("VersionContext", fromCtx, db).Return(1, nil).
+		On("SetVersionContext", fromCtx, db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	ctxScheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("ctx").
+		On("OnUpdateContext", fromCtx, db, 1).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistSchemeContext(ctx, db, ctxScheme)
+	assert.Nil(t, err, "PersistSchemeContext must not return error on update")
+
+	ctxStrategy.AssertExpectations(t)
+	ctxScheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemeContextCreate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	ctxScheme := new(contextSchemeMock)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	ctxScheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreateContext", fromCtx, db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistSchemeContext(ctx, db, ctxScheme)
+	assert.Nil(t, err, "PersistSchemeContext must not return error on create")
+
+	strategy.AssertExpectations(t)
+	ctxScheme.AssertExpectations(t)
+}
+
+func TestPersistSchemeContextCancelled(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistSchemeContext(ctx, db, scheme)
+	assert.Equal(t, context.Canceled, err, "Cancelled context must abort the migration")
+	strategy.AssertExpectations(t)
+}
+
+/////////////////////////////////////////////////////
+// Stubs
+/////////////////////////////////////////////////////
+
+type contextStrategyMock struct {
+	versionStrategyMock
+}
+
+func (m *contextStrategyMock) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	args := m.Called(ctx, db)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *contextStrategyMock) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	return m.Called(ctx, db, version).Error(0)
+}
+
+type contextSchemeMock struct {
+	schemeMock
+}
+
+func (s *contextSchemeMock) OnCreateContext(ctx context.Context, db *sql.DB) error {
+	return s.Called(ctx, db).Error(0)
+}
+
+func (s *contextSchemeMock) OnUpdateContext(ctx context.Context, db *sql.DB, oldVersion int) error {
+	return s.Called(ctx, db, oldVersion).Error(0)
+}
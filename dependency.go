@@ -0,0 +1,106 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by PersistSchemesOrdered, to be checked with
+// errors.Is
+var (
+	ErrCyclicDependency     = errors.New("versioned db: cyclic scheme dependency")
+	ErrUnresolvedDependency = errors.New("versioned db: unresolved scheme dependency")
+)
+
+// SchemeDependency is a scheme along the VersionStrategy names of the
+// schemes that must be migrated before it
+type SchemeDependency struct {
+	Scheme    Scheme
+	DependsOn []string
+}
+
+// PersistSchemesOrdered migrates db to the schemes of deps as
+// PersistSchemes does, in a single transaction, ordering them so every
+// scheme is migrated after those it depends on. Schemes without
+// dependencies between them keep their relative order. It returns an
+// error wrapping ErrCyclicDependency, naming the cycle, or
+// ErrUnresolvedDependency when a dependency is not among deps
+func PersistSchemesOrdered(db *sql.DB, deps []SchemeDependency) error {
+	return DefaultRegistry.PersistSchemesOrdered(db, deps)
+}
+
+// PersistSchemesOrdered is like the package level PersistSchemesOrdered
+// but resolves the strategies of the schemes in r
+func (r *Registry) PersistSchemesOrdered(db *sql.DB, deps []SchemeDependency) error {
+	schemes, err := orderSchemes(deps)
+	if err != nil {
+		return err
+	}
+	return r.PersistSchemes(db, schemes...)
+}
+
+// orderSchemes sorts deps topologically, visiting them depth first in
+// the order given
+func orderSchemes(deps []SchemeDependency) ([]Scheme, error) {
+	byName := make(map[string][]int)
+	for i, dep := range deps {
+		if dep.Scheme == nil {
+			return nil, ErrNilScheme
+		}
+		name := dep.Scheme.VersionStrategy()
+		byName[name] = append(byName[name], i)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var (
+		state   = make([]int, len(deps))
+		path    []string
+		ordered []Scheme
+		visit   func(i int) error
+	)
+	visit = func(i int) error {
+		name := deps[i].Scheme.VersionStrategy()
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := len(path) - 1
+			for path[start] != name {
+				start--
+			}
+			cycle := append(append([]string(nil), path[start:]...), name)
+			return fmt.Errorf("%w: %s", ErrCyclicDependency, strings.Join(cycle, " -> "))
+		}
+
+		state[i] = visiting
+		path = append(path, name)
+		for _, dependency := range deps[i].DependsOn {
+			indexes, ok := byName[dependency]
+			if !ok {
+				return fmt.Errorf("%w: %q depends on %q", ErrUnresolvedDependency, name, dependency)
+			}
+			for _, j := range indexes {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		ordered = append(ordered, deps[i].Scheme)
+		return nil
+	}
+
+	for i := range deps {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,87 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func namedScheme(name string) Scheme {
+	return FuncScheme{
+		VersionFn:         func() int { return 1 },
+		VersionStrategyFn: func() string { return name },
+	}
+}
+
+func TestOrderSchemes(t *testing.T) {
+	users, orders, audit := namedScheme("users"), namedScheme("orders"), namedScheme("audit")
+
+	ordered, err := orderSchemes([]SchemeDependency{
+		{Scheme: orders, DependsOn: []string{"users"}},
+		{Scheme: audit},
+		{Scheme: users},
+	})
+	assert.Nil(t, err, "orderSchemes must not return error")
+
+	var names []string
+	for _, s := range ordered {
+		names = append(names, s.VersionStrategy())
+	}
+	assert.Equal(t, []string{"users", "orders", "audit"}, names, "Dependencies must be migrated first")
+}
+
+func TestOrderSchemesCycle(t *testing.T) {
+	_, err := orderSchemes([]SchemeDependency{
+		{Scheme: namedScheme("a"), DependsOn: []string{"b"}},
+		{Scheme: namedScheme("b"), DependsOn: []string{"c"}},
+		{Scheme: namedScheme("c"), DependsOn: []string{"b"}},
+	})
+	assert.True(t, errors.Is(err, ErrCyclicDependency), "Cycle must be reported")
+	assert.Contains(t, err.Error(), "b -> c -> b", "Cycle must be listed")
+
+	_, err = orderSchemes([]SchemeDependency{{Scheme: namedScheme("a"), DependsOn: []string{"a"}}})
+	assert.True(t, errors.Is(err, ErrCyclicDependency), "Self dependency must be reported")
+}
+
+func TestOrderSchemesUnresolved(t *testing.T) {
+	_, err := orderSchemes([]SchemeDependency{{Scheme: namedScheme("a"), DependsOn: []string{"missing"}}})
+	assert.True(t, errors.Is(err, ErrUnresolvedDependency), "Unknown dependency must be reported")
+}
+
+func TestPersistSchemesOrdered(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("users", NewTableStrategy("users_version"))
+	Register("orders", NewTableStrategy("orders_version"))
+	orders := NewSQLScheme(1, "orders", "CREATE TABLE orders (id int)", nil)
+	users := NewSQLScheme(1, "users", "CREATE TABLE users (id int)", nil)
+
+	expectTableLookup("users_version", false)
+	expectTableLookup("orders_version", false)
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersionTables("users_version")
+	dbMock.ExpectQuery("SELECT version FROM users_version").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO users_version").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO users_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec("CREATE TABLE orders").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersionTables("orders_version")
+	dbMock.ExpectQuery("SELECT version FROM orders_version").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO orders_version").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO orders_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	err := PersistSchemesOrdered(db, []SchemeDependency{
+		{Scheme: orders, DependsOn: []string{"users"}},
+		{Scheme: users},
+	})
+	assert.Nil(t, err, "PersistSchemesOrdered must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Dependencies must be migrated first. Err %q", err)
+	}
+}
@@ -0,0 +1,67 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrDropNotSupported is returned by DropScheme for schemes not
+// implementing DroppableScheme
+var ErrDropNotSupported = errors.New("versioned db: scheme does not support drop")
+
+// DroppableScheme is implemented by schemes able to drop everything
+// they created, such as for test and CI teardowns
+type DroppableScheme interface {
+	Scheme
+	OnDrop(db *sql.DB) error
+}
+
+// asDroppableScheme asserts scheme to DroppableScheme, unless it is a
+// wrapper around a scheme not implementing it
+func asDroppableScheme(scheme Scheme) (DroppableScheme, bool) {
+	s, ok := scheme.(DroppableScheme)
+	return s, ok && wrappedSchemeSupports(scheme, func(scheme Scheme) bool {
+		_, ok := scheme.(DroppableScheme)
+		return ok
+	})
+}
+
+// DropScheme calls the OnDrop of scheme and resets the version recorded
+// by its strategy to zero, in a single transaction rolled back if
+// either fails. Like ForceVersion, the reset bypasses the checks of
+// strategies such as NewMonotonicStrategy. Errors returned by OnDrop
+// are wrapped with ErrMigrationFailed
+func DropScheme(db *sql.DB, scheme Scheme) error {
+	return DefaultRegistry.DropScheme(db, scheme)
+}
+
+// DropScheme is like the package level DropScheme but resolves the
+// strategy of scheme in r
+func (r *Registry) DropScheme(db *sql.DB, scheme Scheme) error {
+	strategy, _, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return err
+	}
+	s, ok := asDroppableScheme(scheme)
+	if !ok {
+		return ErrDropNotSupported
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = s.OnDrop(db); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%w: %w", ErrMigrationFailed, err)
+	}
+	if err = strategySetVersion(withForcedVersion(context.Background()), strategy, db, 0); err != nil {
+		tx.Rollback()
+		return err
+	}
+	logf("scheme dropped")
+	return tx.Commit()
+}
@@ -0,0 +1,79 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	droppable := new(droppableSchemeMock)
+	strategy.On("SetVersion", db, 0).Return(nil)
+	droppable.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnDrop", db).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := DropScheme(db, droppable)
+	assert.Nil(t, err, "DropScheme must not return error")
+
+	strategy.AssertExpectations(t)
+	droppable.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestDropSchemeRollback(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	droppable := new(droppableSchemeMock)
+	droppable.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnDrop", db).Return(someError)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	err := DropScheme(db, droppable)
+	assert.True(t, errors.Is(err, someError), "OnDrop error must be returned")
+	strategy.AssertNotCalled(t, "SetVersion", db, 0)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestDropSchemeNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	assert.Equal(t, ErrDropNotSupported, DropScheme(db, scheme))
+}
+
+// Stubs
+/////////////////////////////////////////////////////
+
+type droppableSchemeMock struct {
+	schemeMock
+}
+
+func (s *droppableSchemeMock) OnDrop(db *sql.DB) error {
+	return s.Called(db).Error(0)
+}
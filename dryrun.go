@@ -0,0 +1,37 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+)
+
+// MigrationPlan describes the migration PersistScheme would run.
+// Action is one of "create", "update" or "noop"
+type MigrationPlan struct {
+	Action      string
+	FromVersion int
+	ToVersion   int
+}
+
+// DryRunScheme runs the migration of db to scheme as PersistScheme
+// would, callbacks included, within a transaction that is rolled back
+// instead of committed. The returned plan tells which migration ran.
+// For nothing to persist every step must run within that transaction,
+// so unless the database is up to date the scheme must implement
+// TxScheme and its strategy TxStrategy; ErrTxNotSupported is returned
+// otherwise, before any callback runs. As OnDowngrade cannot run
+// within a transaction, databases ahead of a scheme implementing
+// SchemeWithDowngrade get ErrTxNotSupported too. Transition hooks are called
+// with db and are not rolled back. MigrationInfoFromContext reports
+// IsDryRun to the callbacks
+func DryRunScheme(db *sql.DB, scheme Scheme, opts ...Option) (*MigrationPlan, error) {
+	opts = append(opts, func(o *options) {
+		o.info.IsDryRun = true
+		o.txOnly = true
+	})
+	res, err := PersistSchemeContext(context.Background(), db, scheme, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationPlan{Action: res.Action.String(), FromVersion: res.FromVersion, ToVersion: res.ToVersion}, nil
+}
@@ -0,0 +1,120 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestDryRunSchemeRollsBack(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	s := NewSQLScheme(2, "table", "", map[int]string{1: "ALTER TABLE users ADD email text"})
+
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectRollback()
+
+	plan, err := DryRunScheme(db, s)
+	assert.Nil(t, err, "DryRunScheme must not return error")
+	assert.Equal(t, &MigrationPlan{Action: "update", FromVersion: 1, ToVersion: 2}, plan)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Dry run must run within the rolled back transaction. Err %q", err)
+	}
+}
+
+func TestDryRunSchemeTxNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	plan, err := DryRunScheme(db, scheme)
+	assert.Equal(t, ErrTxNotSupported, err)
+	assert.Nil(t, plan)
+	scheme.AssertNotCalled(t, "OnUpdate", db, 1)
+	strategy.AssertNotCalled(t, "SetVersion", db, 2)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No transaction must be opened. Err %q", err)
+	}
+}
+
+func TestDryRunSchemeDowngradeNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+	strategy.On("Version", db).Return(3, nil)
+	downgrading.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	plan, err := DryRunScheme(db, downgrading)
+	assert.Equal(t, ErrTxNotSupported, err, "Downgrades cannot be dry run")
+	assert.Nil(t, plan)
+	downgrading.AssertNotCalled(t, "OnDowngrade", db, 2)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No transaction must be opened. Err %q", err)
+	}
+}
+
+func TestDryRunSchemeUpToDate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(2, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	plan, err := DryRunScheme(db, scheme)
+	assert.Nil(t, err, "DryRunScheme must not return error")
+	assert.Equal(t, &MigrationPlan{Action: "noop", FromVersion: 2, ToVersion: 2}, plan)
+}
+
+func TestDryRunSchemeError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	s := NewSQLScheme(1, "table", "CREATE TABLE users (id int)", nil)
+
+	expectTableLookup("schema_version", false)
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", false)
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnError(someError)
+	dbMock.ExpectRollback()
+
+	plan, err := DryRunScheme(db, s)
+	assert.True(t, errors.Is(err, someError), "Callback error must be passed out")
+	assert.Nil(t, plan)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
@@ -0,0 +1,69 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// NewDynamicStrategy returns a strategy delegating to one of
+// versionMap, chosen by the database server version as reported by
+// SELECT version(). Keys are prefixes of that report such as
+// "PostgreSQL 15", the longest matching one is used. defaultStrategy
+// is used when no key matches, it may be nil to make that an error.
+// The server version is queried on every call and never cached, so
+// closed databases are not retained and an upgraded server is noticed
+func NewDynamicStrategy(versionMap map[string]Strategy, defaultStrategy Strategy) Strategy {
+	return &dynamicStrategy{
+		versionMap:      versionMap,
+		defaultStrategy: defaultStrategy,
+	}
+}
+
+type dynamicStrategy struct {
+	versionMap      map[string]Strategy
+	defaultStrategy Strategy
+}
+
+func (s *dynamicStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *dynamicStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *dynamicStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	strategy, err := s.resolve(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	return strategyVersion(ctx, strategy, db)
+}
+
+func (s *dynamicStrategy) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	strategy, err := s.resolve(ctx, db)
+	if err != nil {
+		return err
+	}
+	return strategySetVersion(ctx, strategy, db, version)
+}
+
+func (s *dynamicStrategy) resolve(ctx context.Context, db *sql.DB) (Strategy, error) {
+	var serverVersion string
+	if err := db.QueryRowContext(ctx, "SELECT version()").Scan(&serverVersion); err != nil {
+		return nil, err
+	}
+
+	strategy, prefixLen := s.defaultStrategy, -1
+	for prefix, candidate := range s.versionMap {
+		if len(prefix) > prefixLen && strings.HasPrefix(serverVersion, prefix) {
+			strategy, prefixLen = candidate, len(prefix)
+		}
+	}
+	if strategy == nil {
+		return nil, fmt.Errorf("versioned db: no strategy for server version %q", serverVersion)
+	}
+	return strategy, nil
+}
@@ -0,0 +1,81 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestDynamicStrategyPicksLongestPrefix(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	pg := new(versionStrategyMock)
+	pg15 := new(versionStrategyMock)
+	dynamic := NewDynamicStrategy(map[string]Strategy{
+		"PostgreSQL":    pg,
+		"PostgreSQL 15": pg15,
+	}, strategy)
+
+	dbMock.ExpectQuery("SELECT version()").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("PostgreSQL 15.4 on x86_64-pc-linux-gnu"))
+	pg15.On("Version", db).Return(2, nil)
+
+	v, err := dynamic.Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 2, v, "Version must be read from the matching strategy")
+
+	pg15.On("SetVersion", db, 3).Return(nil)
+	dbMock.ExpectQuery("SELECT version()").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("PostgreSQL 15.4 on x86_64-pc-linux-gnu"))
+
+	assert.Nil(t, dynamic.SetVersion(db, 3), "SetVersion must not return error")
+
+	pg.AssertExpectations(t)
+	pg15.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestDynamicStrategyFallsBackToDefault(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dynamic := NewDynamicStrategy(map[string]Strategy{"PostgreSQL": new(versionStrategyMock)}, strategy)
+
+	dbMock.ExpectQuery("SELECT version()").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("8.0.33"))
+	strategy.On("Version", db).Return(1, nil)
+
+	v, err := dynamic.Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 1, v, "Version must be read from the default strategy")
+	strategy.AssertExpectations(t)
+}
+
+func TestDynamicStrategyWithoutMatch(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dynamic := NewDynamicStrategy(map[string]Strategy{"PostgreSQL": strategy}, nil)
+
+	dbMock.ExpectQuery("SELECT version()").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("8.0.33"))
+
+	_, err := dynamic.Version(db)
+	assert.NotNil(t, err, "An error must be returned when no strategy matches")
+}
+
+func TestDynamicStrategyQueryError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dynamic := NewDynamicStrategy(nil, strategy)
+	dbMock.ExpectQuery("SELECT version()").WillReturnError(someError)
+
+	err := dynamic.SetVersion(db, 1)
+	assert.Equal(t, someError, err, "Query error must be passed out")
+}
@@ -0,0 +1,144 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MigrationEventType tells the stage of the migration a MigrationEvent
+// reports
+type MigrationEventType string
+
+const (
+	// MigrationStarted is emitted before the migration transaction is
+	// opened
+	MigrationStarted MigrationEventType = "started"
+	// MigrationSucceeded is emitted once the migration is committed
+	MigrationSucceeded MigrationEventType = "succeeded"
+	// MigrationFailed is emitted once the migration is rolled back
+	MigrationFailed MigrationEventType = "failed"
+)
+
+// MigrationEvent describes a stage of the migration of a database.
+// SchemeName is only set for schemes implementing fmt.Stringer.
+// Duration and Error are only set on MigrationSucceeded and
+// MigrationFailed events. Metadata holds the migration_id and operator
+// set by WithMigrationID and WithOperator
+type MigrationEvent struct {
+	EventType    MigrationEventType
+	SchemeName   string
+	StrategyName string
+	Action       MigrationAction
+	OldVersion   int
+	NewVersion   int
+	Duration     time.Duration
+	Timestamp    time.Time
+	Error        error
+	IsDryRun     bool
+	Metadata     map[string]string
+}
+
+// EventListener receives the events of the migrations run by
+// PersistScheme. Events are delivered synchronously, from the migrating
+// goroutine
+type EventListener interface {
+	OnMigrationEvent(event MigrationEvent)
+}
+
+// EventListenerFunc adapts a function to an EventListener
+type EventListenerFunc func(event MigrationEvent)
+
+// OnMigrationEvent calls f(event)
+func (f EventListenerFunc) OnMigrationEvent(event MigrationEvent) {
+	f(event)
+}
+
+// WithEventListener makes PersistScheme report its migration events to
+// l. Listeners passed by several options are called in order
+func WithEventListener(l EventListener) Option {
+	return func(o *options) {
+		o.listeners = append(o.listeners, l)
+	}
+}
+
+// startEvent emits the MigrationStarted event of the migration planned
+// by res, returning it to be finished by finishEvent
+func (o *options) startEvent(scheme Scheme, res MigrationResult) MigrationEvent {
+	event := MigrationEvent{
+		EventType:    MigrationStarted,
+		StrategyName: scheme.VersionStrategy(),
+		Action:       res.Action,
+		OldVersion:   res.FromVersion,
+		NewVersion:   res.ToVersion,
+		Timestamp:    time.Now(),
+		IsDryRun:     o.info.IsDryRun,
+		Metadata:     make(map[string]string),
+	}
+	if s, ok := asStringer(scheme); ok {
+		event.SchemeName = s.String()
+	}
+	if o.info.MigrationID != "" {
+		event.Metadata["migration_id"] = o.info.MigrationID
+	}
+	if o.info.Operator != "" {
+		event.Metadata["operator"] = o.info.Operator
+	}
+	o.emit(event)
+	return event
+}
+
+// asStringer asserts scheme to fmt.Stringer, unless it is a wrapper
+// around a scheme not implementing it
+func asStringer(scheme Scheme) (fmt.Stringer, bool) {
+	s, ok := scheme.(fmt.Stringer)
+	return s, ok && wrappedSchemeSupports(scheme, func(scheme Scheme) bool {
+		_, ok := scheme.(fmt.Stringer)
+		return ok
+	})
+}
+
+// finishEvent emits the outcome of the migration started by event
+func (o *options) finishEvent(event MigrationEvent, err error) {
+	o.emit(finished(event, err))
+}
+
+// finished returns the outcome of the migration started by event
+func finished(event MigrationEvent, err error) MigrationEvent {
+	event.EventType = MigrationSucceeded
+	if err != nil {
+		event.EventType = MigrationFailed
+	}
+	event.Duration = time.Since(event.Timestamp)
+	event.Timestamp = time.Now()
+	event.Error = err
+	return event
+}
+
+// txEventListener is implemented by listeners recording the success of
+// the migration within its transaction, so the record is committed
+// with the migration only
+type txEventListener interface {
+	onMigrationEventTx(ctx context.Context, tx *sql.Tx, event MigrationEvent) error
+}
+
+// emitTx reports the success of the migration started by event to the
+// listeners implementing txEventListener, before tx is committed
+func (o *options) emitTx(ctx context.Context, tx *sql.Tx, event MigrationEvent) error {
+	event = finished(event, nil)
+	for _, l := range o.listeners {
+		if l, ok := l.(txEventListener); ok {
+			if err := l.onMigrationEventTx(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (o *options) emit(event MigrationEvent) {
+	for _, l := range o.listeners {
+		l.OnMigrationEvent(event)
+	}
+}
@@ -0,0 +1,82 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventListenerReceivesMigrationEvents(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var events []MigrationEvent
+	listener := EventListenerFunc(func(e MigrationEvent) { events = append(events, e) })
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme, WithEventListener(listener), WithMigrationID("deploy-42"))
+	assert.Nil(t, err, "PersistScheme must not return error on update")
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, MigrationStarted, events[0].EventType)
+		assert.Equal(t, MigrationSucceeded, events[1].EventType)
+		assert.Equal(t, "fake", events[1].StrategyName)
+		assert.Equal(t, 1, events[1].OldVersion)
+		assert.Equal(t, 2, events[1].NewVersion)
+		assert.Equal(t, "deploy-42", events[1].Metadata["migration_id"])
+		assert.Nil(t, events[1].Error)
+	}
+}
+
+func TestEventListenerReceivesFailure(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var events []MigrationEvent
+	listener := EventListenerFunc(func(e MigrationEvent) { events = append(events, e) })
+
+	strategy.On("Version", db).Return(0, nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme, WithEventListener(listener))
+	assert.True(t, errors.Is(err, someError))
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, MigrationFailed, events[1].EventType)
+		assert.True(t, errors.Is(events[1].Error, ErrMigrationFailed))
+	}
+}
+
+func TestEventListenerNotCalledWhenUpToDate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	called := false
+	listener := EventListenerFunc(func(MigrationEvent) { called = true })
+
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme, WithEventListener(listener))
+	assert.Nil(t, err)
+	assert.False(t, called, "Up to date databases must not emit events")
+}
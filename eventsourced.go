@@ -0,0 +1,43 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const defaultEventsTable = "events"
+
+// EventSourcedListener is an EventListener recording every migration
+// PersistScheme commits as a schema_migrated row of an events table.
+// The row is inserted within the migration transaction, so it is only
+// committed with the migration, and a failing insert rolls the
+// migration back
+type EventSourcedListener struct {
+	table string
+}
+
+// NewEventSourcedListener returns a listener inserting the events in
+// tableName, events when empty. The table must exist with the
+// event_type and payload columns, payload receiving the MigrationEvent
+// encoded as JSON. The insert uses $n placeholders, as supported by
+// PostgreSQL
+func NewEventSourcedListener(tableName string) *EventSourcedListener {
+	if tableName == "" {
+		tableName = defaultEventsTable
+	}
+	return &EventSourcedListener{table: tableName}
+}
+
+// OnMigrationEvent does nothing, the events are recorded within the
+// migration transaction instead
+func (l *EventSourcedListener) OnMigrationEvent(event MigrationEvent) {}
+
+func (l *EventSourcedListener) onMigrationEventTx(ctx context.Context, tx *sql.Tx, event MigrationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+l.table+" (event_type, payload) VALUES ($1, $2)", "schema_migrated", string(payload))
+	return err
+}
@@ -0,0 +1,82 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestEventSourcedListener(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil)
+	dbMock.ExpectExec("INSERT INTO schema_events \\(event_type, payload\\) VALUES \\(\\$1, \\$2\\)").
+		WithArgs("schema_migrated", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme, WithEventListener(NewEventSourcedListener("schema_events")))
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("The event must be inserted before the commit. Err %q", err)
+	}
+}
+
+func TestEventSourcedListenerFailureRollsBack(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectExec("INSERT INTO events").WillReturnError(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme, WithEventListener(NewEventSourcedListener("")))
+	assert.True(t, errors.Is(err, someError), "Insert error must be returned")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestEventSourcedListenerNotCalledOnFailure(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(0, nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme, WithEventListener(NewEventSourcedListener("")))
+	assert.True(t, errors.Is(err, ErrMigrationFailed))
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No event must be recorded for failed migrations. Err %q", err)
+	}
+}
@@ -0,0 +1,71 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrMetadataDBUnavailable wraps the errors returned by the factories
+// passed to RegisterWithFactory
+var ErrMetadataDBUnavailable = errors.New("versioned db: metadata database unavailable")
+
+// RegisterWithFactory registers strategy like Register does, but makes
+// it read and write versions in the database returned by factory, such
+// as a central catalog, instead of the one being migrated. The scheme
+// callbacks still run against the database passed to PersistScheme.
+// factory is called on every version read and write, it should return
+// a shared *sql.DB, which is never closed
+func RegisterWithFactory(name string, strategy Strategy, factory func() (*sql.DB, error)) {
+	DefaultRegistry.RegisterWithFactory(name, strategy, factory)
+}
+
+// RegisterWithFactory is like the package level RegisterWithFactory
+// but registers strategy in r
+func (r *Registry) RegisterWithFactory(name string, strategy Strategy, factory func() (*sql.DB, error)) {
+	if strategy == nil || factory == nil {
+		panic("versioned db: RegisterWithFactory strategy or factory is nil")
+	}
+	r.Register(name, &factoryStrategy{inner: strategy, factory: factory})
+}
+
+type factoryStrategy struct {
+	inner   Strategy
+	factory func() (*sql.DB, error)
+}
+
+func (s *factoryStrategy) metadataDB() (*sql.DB, error) {
+	db, err := s.factory()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMetadataDBUnavailable, err)
+	}
+	if db == nil {
+		return nil, fmt.Errorf("%w: %w", ErrMetadataDBUnavailable, ErrNilDB)
+	}
+	return db, nil
+}
+
+func (s *factoryStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *factoryStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *factoryStrategy) VersionContext(ctx context.Context, _ *sql.DB) (int, error) {
+	db, err := s.metadataDB()
+	if err != nil {
+		return 0, err
+	}
+	return strategyVersion(ctx, s.inner, db)
+}
+
+func (s *factoryStrategy) SetVersionContext(ctx context.Context, _ *sql.DB, version int) error {
+	db, err := s.metadataDB()
+	if err != nil {
+		return err
+	}
+	return strategySetVersion(ctx, s.inner, db, version)
+}
@@ -0,0 +1,54 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRegisterWithFactory(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	catalog, catalogMock, _ := sqlmock.New()
+	defer catalog.Close()
+
+	inner := new(versionStrategyMock)
+	inner.
+		On("Version", catalog).Return(0, nil).
+		On("SetVersion", catalog, 1).Return(nil)
+	RegisterWithFactory("catalog", inner, func() (*sql.DB, error) { return catalog, nil })
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("catalog").
+		On("OnCreate", db).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	inner.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	for _, m := range []sqlmock.Sqlmock{dbMock, catalogMock} {
+		if err = m.ExpectationsWereMet(); err != nil {
+			t.Errorf("Expectations not met. Err %q", err)
+		}
+	}
+}
+
+func TestRegisterWithFactoryError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	RegisterWithFactory("catalog", new(versionStrategyMock), func() (*sql.DB, error) { return nil, someError })
+
+	_, err := CurrentVersion(db, "catalog")
+	assert.True(t, errors.Is(err, ErrMetadataDBUnavailable), "Factory errors must be wrapped")
+	assert.True(t, errors.Is(err, someError), "Factory error must be kept")
+}
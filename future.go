@@ -0,0 +1,79 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// FuturePolicy tells PersistScheme what to do when the database version
+// is ahead of the scheme version, such as when an older binary runs
+// against a database migrated by a newer one
+type FuturePolicy int
+
+const (
+	// futurePolicyDefault applies when no policy is set. Schemes
+	// implementing SchemeWithDowngrade are downgraded, as under
+	// FuturePolicyDowngrade, the others make PersistScheme return an
+	// error wrapping ErrVersionDowngrade
+	futurePolicyDefault FuturePolicy = iota
+	// FuturePolicyIgnore leaves the database untouched and returns nil,
+	// even for schemes implementing SchemeWithDowngrade
+	FuturePolicyIgnore
+	// FuturePolicyError returns an error wrapping ErrDatabaseAhead
+	FuturePolicyError
+	// FuturePolicyDowngrade migrates the database down by calling the
+	// OnDowngrade of the scheme, which must implement
+	// SchemeWithDowngrade
+	FuturePolicyDowngrade
+)
+
+// ErrDatabaseAhead is returned under FuturePolicyError when the
+// database version is ahead of the scheme version
+var ErrDatabaseAhead = errors.New("versioned db: database version is ahead of the scheme")
+
+// ErrVersionDowngrade is returned, along with ErrDatabaseAhead, when
+// no FuturePolicy is set and the database version is ahead of a scheme
+// not implementing SchemeWithDowngrade. FuturePolicyIgnore must be set
+// for such databases to be left untouched without error
+var ErrVersionDowngrade = errors.New("versioned db: database would be downgraded")
+
+// ErrDowngradeNotSupported is returned when a database must be migrated
+// down to a scheme not implementing SchemeWithDowngrade
+var ErrDowngradeNotSupported = errors.New("versioned db: scheme does not support downgrade")
+
+// SchemeWithDowngrade is implemented by schemes able to migrate a
+// database down from a newer version to targetVersion, their own.
+// Unless another FuturePolicy is set, PersistScheme calls OnDowngrade
+// whenever the database is ahead of such a scheme
+type SchemeWithDowngrade interface {
+	Scheme
+	OnDowngrade(db *sql.DB, targetVersion int) error
+}
+
+// asDowngradeScheme asserts scheme to SchemeWithDowngrade, unless it is
+// a wrapper around a scheme not implementing it
+func asDowngradeScheme(scheme Scheme) (SchemeWithDowngrade, bool) {
+	s, ok := scheme.(SchemeWithDowngrade)
+	return s, ok && wrappedSchemeSupports(scheme, func(scheme Scheme) bool {
+		_, ok := scheme.(SchemeWithDowngrade)
+		return ok
+	})
+}
+
+// WithFutureDatabasePolicy sets what PersistScheme does when the
+// database version is ahead of the scheme version
+func WithFutureDatabasePolicy(p FuturePolicy) Option {
+	return func(o *options) {
+		o.futurePolicy = p
+	}
+}
+
+// downgrades tells whether a database ahead of scheme must be migrated
+// down to it
+func (o *options) downgrades(scheme Scheme) bool {
+	if o.futurePolicy == futurePolicyDefault {
+		_, ok := asDowngradeScheme(scheme)
+		return ok
+	}
+	return o.futurePolicy == FuturePolicyDowngrade
+}
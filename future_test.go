@@ -0,0 +1,151 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuturePolicyIgnore(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(3, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme, WithFutureDatabasePolicy(FuturePolicyIgnore))
+	assert.Nil(t, err, "Database ahead must be ignored")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestDatabaseAheadByDefault(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(3, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, ErrVersionDowngrade), "ErrVersionDowngrade must be returned by default")
+	assert.True(t, errors.Is(err, ErrDatabaseAhead), "ErrDatabaseAhead must be wrapped")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No transaction must be opened. Err %q", err)
+	}
+}
+
+func TestFuturePolicyError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(3, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme, WithFutureDatabasePolicy(FuturePolicyError))
+	assert.True(t, errors.Is(err, ErrDatabaseAhead), "ErrDatabaseAhead must be returned")
+}
+
+func TestFuturePolicyDowngrade(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+
+	strategy.
+		On("Version", db).Return(3, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	downgrading.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnDowngrade", db, 2).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, downgrading, WithFutureDatabasePolicy(FuturePolicyDowngrade))
+	assert.Nil(t, err, "PersistScheme must not return error on downgrade")
+
+	strategy.AssertExpectations(t)
+	downgrading.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestFuturePolicyDowngradeNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(3, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme, WithFutureDatabasePolicy(FuturePolicyDowngrade))
+	assert.Equal(t, ErrDowngradeNotSupported, err)
+}
+
+func TestDowngradeByDefault(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+
+	strategy.
+		On("Version", db).Return(3, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	downgrading.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnDowngrade", db, 1).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, downgrading)
+	assert.True(t, errors.Is(err, someError), "Schemes implementing OnDowngrade must be downgraded by default")
+	downgrading.AssertExpectations(t)
+}
+
+func TestFuturePolicyIgnoreSkipsDowngrade(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	downgrading := new(downgradeSchemeMock)
+
+	strategy.On("Version", db).Return(3, nil)
+	downgrading.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, downgrading, WithFutureDatabasePolicy(FuturePolicyIgnore))
+	assert.Nil(t, err)
+	downgrading.AssertNotCalled(t, "OnDowngrade", db, 1)
+}
+
+/////////////////////////////////////////////////////
+// Stubs
+/////////////////////////////////////////////////////
+
+type downgradeSchemeMock struct {
+	schemeMock
+}
+
+func (s *downgradeSchemeMock) OnDowngrade(db *sql.DB, targetVersion int) error {
+	return s.Called(db, targetVersion).Error(0)
+}
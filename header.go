@@ -0,0 +1,50 @@
+package version
+
+import "strings"
+
+// MigrationHeader holds the metadata declared as "-- Key: Value"
+// comments at the top of a SQL migration script. Keys are stored in
+// lower case
+type MigrationHeader map[string]string
+
+// ParseMigrationHeader extracts the "-- Key: Value" comment lines
+// leading sql. Parsing stops at the first line that is neither blank
+// nor a single line comment; comments not in the key value form are
+// ignored. When a key is repeated the last value wins
+func ParseMigrationHeader(sql string) MigrationHeader {
+	header := make(MigrationHeader)
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(line[:i], "--"))
+		if key == "" || strings.ContainsAny(key, " \t") {
+			continue
+		}
+		header[strings.ToLower(key)] = strings.TrimSpace(line[i+1:])
+	}
+	return header
+}
+
+// Get returns the value declared for key, regardless of its case
+func (h MigrationHeader) Get(key string) string {
+	return h[strings.ToLower(key)]
+}
+
+// Description returns the value of the Description key
+func (h MigrationHeader) Description() string {
+	return h.Get("description")
+}
+
+// Author returns the value of the Author key
+func (h MigrationHeader) Author() string {
+	return h.Get("author")
+}
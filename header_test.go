@@ -0,0 +1,34 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMigrationHeader(t *testing.T) {
+	header := ParseMigrationHeader(`
+-- Description: Add users table
+-- Author:  Jane Doe
+-- a free form comment
+-- Date: 2020-01-02 10:00
+CREATE TABLE users (id int);
+-- Ignored: after the first statement
+`)
+
+	assert.Equal(t, MigrationHeader{
+		"description": "Add users table",
+		"author":      "Jane Doe",
+		"date":        "2020-01-02 10:00",
+	}, header)
+	assert.Equal(t, "Add users table", header.Description())
+	assert.Equal(t, "Jane Doe", header.Author())
+	assert.Equal(t, "2020-01-02 10:00", header.Get("DATE"), "Keys must be case insensitive")
+}
+
+func TestParseMigrationHeaderWithoutHeader(t *testing.T) {
+	header := ParseMigrationHeader("CREATE TABLE users (id int);")
+
+	assert.Empty(t, header, "Scripts without header must have no metadata")
+	assert.Equal(t, "", header.Description())
+}
@@ -0,0 +1,85 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrHistoryNotSupported is returned by GetMigrationHistory for
+// strategies not implementing HistoryStrategy
+var ErrHistoryNotSupported = errors.New("versioned db: strategy does not record history")
+
+// VersionEntry is a version recorded by a HistoryStrategy. Action is
+// one of "create", "update" or "downgrade". Duration is the time the
+// migration took until the version was recorded, zero when unknown.
+// Description is the change log of the version for schemes
+// implementing SchemeChangeLog
+type VersionEntry struct {
+	Version     int
+	Action      string
+	AppliedAt   time.Time
+	Duration    time.Duration
+	Description string
+}
+
+// SchemeChangeLog is implemented by schemes documenting the changes of
+// each of their versions, recorded along the version by the strategies
+// keeping a history
+type SchemeChangeLog interface {
+	ChangeLog(version int) string
+}
+
+// asSchemeChangeLog asserts scheme to SchemeChangeLog, unless it is a
+// wrapper around a scheme not implementing it
+func asSchemeChangeLog(scheme Scheme) (SchemeChangeLog, bool) {
+	s, ok := scheme.(SchemeChangeLog)
+	return s, ok && wrappedSchemeSupports(scheme, func(scheme Scheme) bool {
+		_, ok := scheme.(SchemeChangeLog)
+		return ok
+	})
+}
+
+// HistoryStrategy is implemented by strategies keeping every version
+// recorded, and not only the current one
+type HistoryStrategy interface {
+	Strategy
+	VersionHistory(db *sql.DB) ([]VersionEntry, error)
+}
+
+// GetMigrationHistory returns the versions recorded by the strategy
+// registered by strategyName, oldest first
+func GetMigrationHistory(db *sql.DB, strategyName string) ([]VersionEntry, error) {
+	return DefaultRegistry.GetMigrationHistory(db, strategyName)
+}
+
+// GetMigrationHistory is like the package level GetMigrationHistory
+// but looks strategyName up in r
+func (r *Registry) GetMigrationHistory(db *sql.DB, strategyName string) ([]VersionEntry, error) {
+	var strategy Strategy
+
+	if db == nil {
+		return nil, ErrNilDB
+	}
+
+	if strategy = r.lookup(strategyName); strategy == nil {
+		return nil, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, strategyName)
+	}
+
+	s, ok := strategy.(HistoryStrategy)
+	if !ok {
+		return nil, ErrHistoryNotSupported
+	}
+	return s.VersionHistory(db)
+}
+
+func historyAction(from, to int) string {
+	switch {
+	case from == 0:
+		return "create"
+	case to < from:
+		return "downgrade"
+	}
+	return "update"
+}
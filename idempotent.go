@@ -0,0 +1,161 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// IdempotentScheme wraps a scheme whose OnCreate is safe to run several
+// times against the same database. PersistScheme calls its OnCreate
+// whenever the database version is lower than or equal to Baseline,
+// even if the database is up to date, and then records the scheme
+// version. Test setups can therefore persist it repeatedly, including
+// after resetting the version with ForceVersion. The optional interfaces
+// of the wrapped scheme, such as TxScheme, ChecksumScheme or
+// DroppableScheme, are forwarded to it
+type IdempotentScheme struct {
+	Scheme
+	Baseline int
+}
+
+// NewIdempotentScheme wraps scheme re-running its OnCreate up to the
+// baseline version
+func NewIdempotentScheme(scheme Scheme, baseline int) *IdempotentScheme {
+	return &IdempotentScheme{Scheme: scheme, Baseline: baseline}
+}
+
+func (s *IdempotentScheme) recreates(dbVersion int) bool {
+	return dbVersion <= s.Baseline
+}
+
+// recreator is implemented by schemes asking OnCreate to run again
+// for some database versions other than zero
+type recreator interface {
+	recreates(dbVersion int) bool
+}
+
+func schemeRecreates(scheme Scheme, dbVersion int) bool {
+	s, ok := scheme.(recreator)
+	return ok && s.recreates(dbVersion)
+}
+
+// schemeWrapper is implemented by schemes forwarding the optional
+// interfaces of the scheme they wrap. Those interfaces only hold when
+// the wrapped scheme implements them too
+type schemeWrapper interface {
+	unwrapScheme() Scheme
+}
+
+func (s *IdempotentScheme) unwrapScheme() Scheme {
+	return s.Scheme
+}
+
+// wrappedSchemeSupports tells whether scheme, already asserted to
+// implement an optional interface, really supports it, that is,
+// whether every scheme it wraps implements it as well
+func wrappedSchemeSupports(scheme Scheme, implements func(Scheme) bool) bool {
+	for {
+		w, ok := scheme.(schemeWrapper)
+		if !ok {
+			return true
+		}
+		scheme = w.unwrapScheme()
+		if !implements(scheme) {
+			return false
+		}
+	}
+}
+
+// OnCreateContext forwards to the wrapped scheme
+func (s *IdempotentScheme) OnCreateContext(ctx context.Context, db *sql.DB) error {
+	return schemeCreate(ctx, s.Scheme, db)
+}
+
+// OnUpdateContext forwards to the wrapped scheme
+func (s *IdempotentScheme) OnUpdateContext(ctx context.Context, db *sql.DB, oldVersion int) error {
+	return schemeUpdate(ctx, s.Scheme, db, oldVersion)
+}
+
+// OnCreateTx forwards to the wrapped scheme, returning
+// ErrTxNotSupported unless it implements TxScheme
+func (s *IdempotentScheme) OnCreateTx(tx *sql.Tx) error {
+	return s.onCreateTx(context.Background(), tx)
+}
+
+// OnUpdateTx forwards to the wrapped scheme, returning
+// ErrTxNotSupported unless it implements TxScheme
+func (s *IdempotentScheme) OnUpdateTx(tx *sql.Tx, oldVersion int) error {
+	return s.onUpdateTx(context.Background(), tx, oldVersion)
+}
+
+func (s *IdempotentScheme) onCreateTx(ctx context.Context, tx *sql.Tx) error {
+	inner, ok := s.Scheme.(TxScheme)
+	if !ok {
+		return ErrTxNotSupported
+	}
+	return schemeCreateTx(ctx, inner, tx)
+}
+
+func (s *IdempotentScheme) onUpdateTx(ctx context.Context, tx *sql.Tx, oldVersion int) error {
+	inner, ok := s.Scheme.(TxScheme)
+	if !ok {
+		return ErrTxNotSupported
+	}
+	return schemeUpdateTx(ctx, inner, tx, oldVersion)
+}
+
+// OnDowngrade forwards to the wrapped scheme, returning
+// ErrDowngradeNotSupported unless it implements SchemeWithDowngrade
+func (s *IdempotentScheme) OnDowngrade(db *sql.DB, targetVersion int) error {
+	inner, ok := s.Scheme.(SchemeWithDowngrade)
+	if !ok {
+		return ErrDowngradeNotSupported
+	}
+	return inner.OnDowngrade(db, targetVersion)
+}
+
+// Checksum forwards to the wrapped scheme, returning
+// ErrChecksumNotSupported unless it implements ChecksumScheme
+func (s *IdempotentScheme) Checksum(version int) (string, error) {
+	inner, ok := s.Scheme.(ChecksumScheme)
+	if !ok {
+		return "", ErrChecksumNotSupported
+	}
+	return inner.Checksum(version)
+}
+
+// OnDrop forwards to the wrapped scheme, returning ErrDropNotSupported
+// unless it implements DroppableScheme
+func (s *IdempotentScheme) OnDrop(db *sql.DB) error {
+	inner, ok := s.Scheme.(DroppableScheme)
+	if !ok {
+		return ErrDropNotSupported
+	}
+	return inner.OnDrop(db)
+}
+
+// ChangeLog forwards to the wrapped scheme, returning an empty change
+// log unless it implements SchemeChangeLog
+func (s *IdempotentScheme) ChangeLog(version int) string {
+	inner, ok := s.Scheme.(SchemeChangeLog)
+	if !ok {
+		return ""
+	}
+	return inner.ChangeLog(version)
+}
+
+// String forwards to the wrapped scheme, returning an empty name
+// unless it implements fmt.Stringer
+func (s *IdempotentScheme) String() string {
+	inner, ok := s.Scheme.(fmt.Stringer)
+	if !ok {
+		return ""
+	}
+	return inner.String()
+}
+
+// Description forwards to the wrapped scheme
+func (s *IdempotentScheme) Description() string {
+	return schemeDescription(s.Scheme)
+}
@@ -0,0 +1,184 @@
+package version
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestIdempotentSchemeRecreatesUpToDateDatabase(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, NewIdempotentScheme(scheme, 1))
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+}
+
+func TestIdempotentSchemeUpdatesAboveBaseline(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(2, nil).
+		On("SetVersion", db, 3).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 2).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, NewIdempotentScheme(scheme, 1))
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+}
+
+func TestIdempotentSchemeForwardsOptionalInterfaces(t *testing.T) {
+	wrapped := NewIdempotentScheme(NewSQLScheme(2, "fake", "CREATE TABLE users (id int)", nil), 1)
+	_, ok := asTxScheme(wrapped)
+	assert.True(t, ok, "TxScheme of the wrapped scheme must be forwarded")
+	_, ok = asChecksumScheme(wrapped)
+	assert.True(t, ok, "ChecksumScheme of the wrapped scheme must be forwarded")
+	_, ok = asDowngradeScheme(wrapped)
+	assert.False(t, ok, "SchemeWithDowngrade is not implemented by the wrapped scheme")
+
+	plain := NewIdempotentScheme(new(schemeMock), 1)
+	_, ok = asTxScheme(plain)
+	assert.False(t, ok, "TxScheme is not implemented by the wrapped scheme")
+	_, ok = asChecksumScheme(plain)
+	assert.False(t, ok, "ChecksumScheme is not implemented by the wrapped scheme")
+	assert.Equal(t, ErrTxNotSupported, plain.OnCreateTx(nil))
+	assert.Equal(t, ErrDowngradeNotSupported, plain.OnDowngrade(nil, 1))
+}
+
+func TestIdempotentSchemeChecksum(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	store := make(checksumStoreMock)
+	Register("checksum", NewChecksumStrategy(strategy, store))
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, NewIdempotentScheme(NewSQLScheme(1, "checksum", "CREATE TABLE users (id int)", nil), 1))
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Len(t, store, 1, "Checksum of the wrapped scheme must be recorded")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestIdempotentSchemeDrop(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	droppable := new(droppableSchemeMock)
+	strategy.On("SetVersion", db, 0).Return(nil)
+	droppable.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnDrop", db).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := DropScheme(db, NewIdempotentScheme(droppable, 1))
+	assert.Nil(t, err, "DropScheme must not return error")
+
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+	assert.Equal(t, ErrDropNotSupported, DropScheme(db, NewIdempotentScheme(scheme, 1)))
+
+	strategy.AssertExpectations(t)
+	droppable.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestIdempotentSchemeChangeLog(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	s, err := LoadSQLSchemeFromFS(fstest.MapFS{
+		"create.sql":      {Data: []byte("SELECT 1")},
+		"update_1.sql":    {Data: []byte("CREATE TABLE users (id int)")},
+		"update_2.sql":    {Data: []byte("ALTER TABLE users ADD email text")},
+		"changelog_3.txt": {Data: []byte("Add email to users\n")},
+	}, 3, "table")
+	assert.Nil(t, err)
+
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").
+		WithArgs(3, "update", sqlmock.AnyArg(), sqlmock.AnyArg(), "Add email to users").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	_, err = PersistScheme(db, NewIdempotentScheme(s, 1))
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestIdempotentSchemeName(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	scheme.On("VersionStrategy").Return("fake")
+	o := &options{}
+
+	named := NewIdempotentScheme(scheme, 1)
+	event := o.startEvent(named, MigrationResult{})
+	assert.Equal(t, scheme.String(), event.SchemeName, "SchemeName must be forwarded to the wrapped scheme")
+
+	unnamed := NewIdempotentScheme(namedScheme("users"), 1)
+	_, ok := asStringer(unnamed)
+	assert.False(t, ok, "fmt.Stringer is not implemented by the wrapped scheme")
+	event = o.startEvent(unnamed, MigrationResult{})
+	assert.Empty(t, event.SchemeName)
+}
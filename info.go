@@ -0,0 +1,39 @@
+package version
+
+import (
+	"context"
+	"time"
+)
+
+// MigrationInfo describes the migration run calling a ContextScheme
+// callback
+type MigrationInfo struct {
+	IsDryRun    bool
+	MigrationID string
+	Operator    string
+	StartedAt   time.Time
+}
+
+type migrationInfoKey struct{}
+
+// WithMigrationID sets the MigrationID reported to the callbacks
+func WithMigrationID(id string) Option {
+	return func(o *options) {
+		o.info.MigrationID = id
+	}
+}
+
+// WithOperator sets the Operator reported to the callbacks
+func WithOperator(name string) Option {
+	return func(o *options) {
+		o.info.Operator = name
+	}
+}
+
+// MigrationInfoFromContext returns the MigrationInfo of the migration
+// running with ctx. It reports false when ctx does not belong to a
+// migration
+func MigrationInfoFromContext(ctx context.Context) (MigrationInfo, bool) {
+	info, ok := ctx.Value(migrationInfoKey{}).(MigrationInfo)
+	return info, ok
+}
@@ -0,0 +1,50 @@
+package version
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMigrationInfoFromCallbackContext(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var (
+		info  MigrationInfo
+		found bool
+	)
+	ctxScheme := new(contextSchemeMock)
+	before := time.Now()
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	ctxScheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreateContext", mock.Anything, db).Return(nil).
+		Run(func(args mock.Arguments) {
+			info, found = MigrationInfoFromContext(args.Get(0).(context.Context))
+		})
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, ctxScheme, WithMigrationID("deploy-42"), WithOperator("ops"))
+	assert.Nil(t, err, "PersistScheme must not return error on create")
+
+	assert.True(t, found, "MigrationInfo must be stored in the callback context")
+	assert.Equal(t, "deploy-42", info.MigrationID)
+	assert.Equal(t, "ops", info.Operator)
+	assert.False(t, info.IsDryRun)
+	assert.False(t, info.StartedAt.Before(before), "StartedAt must be the migration start")
+}
+
+func TestMigrationInfoFromContextOutsideMigration(t *testing.T) {
+	_, found := MigrationInfoFromContext(context.Background())
+	assert.False(t, found)
+}
@@ -0,0 +1,53 @@
+package version
+
+import (
+	"log"
+	"sync"
+)
+
+// Logger receives the log lines of the migrations, such as the version
+// found and the action chosen. *log.Logger implements it
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
+
+var (
+	// DiscardLogger ignores every log line, it is the default logger
+	DiscardLogger Logger = discardLogger{}
+	// StdLogger writes the log lines to the standard logger of the log
+	// package
+	StdLogger Logger = stdLogger{}
+)
+
+var (
+	loggerMu sync.RWMutex
+	logger   = DiscardLogger
+)
+
+// SetLogger makes the package write its log lines to l. A nil l
+// restores DiscardLogger
+func SetLogger(l Logger) {
+	if l == nil {
+		l = DiscardLogger
+	}
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = l
+}
+
+func logf(format string, args ...interface{}) {
+	loggerMu.RLock()
+	l := logger
+	loggerMu.RUnlock()
+	l.Printf("versioned db: "+format, args...)
+}
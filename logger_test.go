@@ -0,0 +1,67 @@
+package version
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestSetLogger(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	l := new(recordingLogger)
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error on update")
+
+	assert.Equal(t, []string{
+		`versioned db: using strategy "fake" for scheme version 2`,
+		"versioned db: database at version 1, update to version 2",
+		"versioned db: migration committed",
+	}, l.lines)
+}
+
+func TestSetLoggerRollback(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	l := new(recordingLogger)
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	strategy.On("Version", db).Return(0, nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme)
+	assert.NotNil(t, err)
+	assert.Contains(t, l.lines, "versioned db: migration rolled back: versioned db: migration failed: SomeError")
+}
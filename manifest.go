@@ -0,0 +1,189 @@
+package version
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const manifestHashPrefix = "SHA256="
+
+// ManifestFile holds the checksums of the migration scripts of one or
+// more schemes, as stored in a migrations.sum file. Each line of the
+// file has the form "schemeName version SHA256=hexhash"
+type ManifestFile struct {
+	hashes map[manifestKey]string
+}
+
+type manifestKey struct {
+	scheme  string
+	version int
+}
+
+// ChecksumMismatchError is returned when the checksum of a migration
+// script differs from the one recorded in a ManifestFile. Expected or
+// Actual are empty when the script is missing from the manifest or from
+// the file system respectively
+type ChecksumMismatchError struct {
+	Scheme   string
+	Version  int
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	switch {
+	case e.Expected == "":
+		return fmt.Sprintf("versioned db: %s version %d is missing from the manifest", e.Scheme, e.Version)
+	case e.Actual == "":
+		return fmt.Sprintf("versioned db: %s version %d is missing from the file system", e.Scheme, e.Version)
+	}
+	return fmt.Sprintf("versioned db: %s version %d checksum mismatch, manifest has %s, got %s",
+		e.Scheme, e.Version, e.Expected, e.Actual)
+}
+
+// NewManifestFile returns an empty manifest
+func NewManifestFile() *ManifestFile {
+	return &ManifestFile{hashes: make(map[manifestKey]string)}
+}
+
+// ReadManifestFile parses a migrations.sum file from r. Blank lines are
+// ignored
+func ReadManifestFile(r io.Reader) (*ManifestFile, error) {
+	m := NewManifestFile()
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !strings.HasPrefix(fields[2], manifestHashPrefix) {
+			return nil, fmt.Errorf("versioned db: malformed manifest line %d", n)
+		}
+		version, err := strconv.Atoi(fields[1])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("versioned db: invalid version on manifest line %d", n)
+		}
+		m.Set(fields[0], version, strings.TrimPrefix(fields[2], manifestHashPrefix))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Set records hash, hex encoded, as the checksum of version of scheme
+func (m *ManifestFile) Set(scheme string, version int, hash string) {
+	m.hashes[manifestKey{scheme, version}] = hash
+}
+
+// Hash returns the checksum recorded for version of scheme
+func (m *ManifestFile) Hash(scheme string, version int) (string, bool) {
+	hash, ok := m.hashes[manifestKey{scheme, version}]
+	return hash, ok
+}
+
+// WriteTo writes the manifest to w, sorted by scheme name and version
+func (m *ManifestFile) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for _, key := range m.keys() {
+		n, err := fmt.Fprintf(w, "%s %d %s%s\n", key.scheme, key.version, manifestHashPrefix, m.hashes[key])
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Verify compares the manifest entries of scheme against the scripts
+// found in fsys, returning a *ChecksumMismatchError for the first
+// discrepancy. Scripts are matched to versions as in GenerateManifest
+func (m *ManifestFile) Verify(fsys fs.FS, scheme string) error {
+	current, err := GenerateManifest(fsys, scheme)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range current.keys() {
+		expected, _ := m.Hash(scheme, key.version)
+		if actual := current.hashes[key]; expected != actual {
+			return &ChecksumMismatchError{Scheme: scheme, Version: key.version, Expected: expected, Actual: actual}
+		}
+	}
+	for _, key := range m.keys() {
+		if _, ok := current.hashes[key]; key.scheme == scheme && !ok {
+			return &ChecksumMismatchError{Scheme: scheme, Version: key.version, Expected: m.hashes[key]}
+		}
+	}
+	return nil
+}
+
+func (m *ManifestFile) keys() []manifestKey {
+	keys := make([]manifestKey, 0, len(m.hashes))
+	for key := range m.hashes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].scheme != keys[j].scheme {
+			return keys[i].scheme < keys[j].scheme
+		}
+		return keys[i].version < keys[j].version
+	})
+	return keys
+}
+
+// GenerateManifest computes the checksums of the scripts at the root of
+// fsys, laid out as for LoadSQLSchemeFromFS, and records them under
+// scheme. Each script is recorded at the version it migrates to:
+// create.sql at version 1 and update_N.sql at version N+1. Other files
+// are ignored
+func GenerateManifest(fsys fs.FS, scheme string) (*ManifestFile, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewManifestFile()
+	for _, name := range names {
+		version, ok := scriptVersion(path.Base(name))
+		if !ok {
+			continue
+		}
+		if _, dup := m.Hash(scheme, version); dup {
+			return nil, fmt.Errorf("versioned db: more than one script for version %d of %s", version, scheme)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		m.Set(scheme, version, hex.EncodeToString(sum[:]))
+	}
+	return m, nil
+}
+
+// scriptVersion returns the version the script name of a SQL file
+// scheme migrates to
+func scriptVersion(name string) (int, bool) {
+	if name == "create.sql" {
+		return 1, true
+	}
+	if !strings.HasPrefix(name, "update_") || !strings.HasSuffix(name, ".sql") {
+		return 0, false
+	}
+	oldVersion, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "update_"), ".sql"))
+	if err != nil || oldVersion < 0 {
+		return 0, false
+	}
+	return oldVersion + 1, true
+}
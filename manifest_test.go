@@ -0,0 +1,95 @@
+package version
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestFileRoundTrip(t *testing.T) {
+	m := NewManifestFile()
+	m.Set("users", 2, "bb")
+	m.Set("accounts", 1, "cc")
+	m.Set("users", 1, "aa")
+
+	var buf bytes.Buffer
+	_, err := m.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "accounts 1 SHA256=cc\nusers 1 SHA256=aa\nusers 2 SHA256=bb\n", buf.String(),
+		"Entries must be sorted by scheme and version")
+
+	read, err := ReadManifestFile(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, m, read)
+}
+
+func TestReadManifestFileMalformed(t *testing.T) {
+	_, err := ReadManifestFile(strings.NewReader("users 1 aa\n"))
+	assert.NotNil(t, err, "Lines without hash prefix must be rejected")
+
+	_, err = ReadManifestFile(strings.NewReader("users one SHA256=aa\n"))
+	assert.NotNil(t, err, "Lines without numeric version must be rejected")
+}
+
+func TestManifestFileVerify(t *testing.T) {
+	fsys := fstest.MapFS{
+		"create.sql":   {Data: []byte("CREATE TABLE users (id int);")},
+		"update_1.sql": {Data: []byte("ALTER TABLE users ADD email text;")},
+		"README.md":    {Data: []byte("ignored")},
+	}
+
+	m, err := GenerateManifest(fsys, "users")
+	assert.Nil(t, err)
+	assert.Nil(t, m.Verify(fsys, "users"), "Generated manifest must match its file system")
+
+	fsys["update_1.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE users ADD mail text;")}
+	err = m.Verify(fsys, "users")
+	if assert.IsType(t, &ChecksumMismatchError{}, err) {
+		assert.Equal(t, 2, err.(*ChecksumMismatchError).Version)
+	}
+
+	delete(fsys, "update_1.sql")
+	err = m.Verify(fsys, "users")
+	if assert.IsType(t, &ChecksumMismatchError{}, err) {
+		assert.Empty(t, err.(*ChecksumMismatchError).Actual, "Missing script must be reported")
+	}
+}
+
+func TestGenerateManifestDuplicatedVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"create.sql":   {Data: []byte("a")},
+		"update_0.sql": {Data: []byte("b")},
+	}
+
+	_, err := GenerateManifest(fsys, "users")
+	assert.NotNil(t, err, "Two scripts for the same version must be rejected")
+}
+
+func TestGenerateManifestSQLSchemeLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"create.sql":      {Data: []byte("CREATE TABLE users (id int, email text, name text);")},
+		"update_1.sql":    {Data: []byte("ALTER TABLE users ADD email text;")},
+		"update_2.sql":    {Data: []byte("ALTER TABLE users ADD name text;")},
+		"changelog_2.txt": {Data: []byte("Add email")},
+	}
+	_, err := LoadSQLSchemeFromFS(fsys, 3, "fake")
+	assert.Nil(t, err, "The fixture must be a valid SQL scheme directory")
+
+	m, err := GenerateManifest(fsys, "users")
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	_, err = m.WriteTo(&buf)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3, "Every script must be recorded") {
+		assert.True(t, strings.HasPrefix(lines[0], "users 1 SHA256="), "create.sql must be recorded at version 1")
+		assert.True(t, strings.HasPrefix(lines[1], "users 2 SHA256="), "update_1.sql must be recorded at version 2")
+		assert.True(t, strings.HasPrefix(lines[2], "users 3 SHA256="), "update_2.sql must be recorded at version 3")
+	}
+	_, ok := m.Hash("users", 2)
+	assert.True(t, ok)
+}
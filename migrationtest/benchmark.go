@@ -0,0 +1,37 @@
+// Package migrationtest provides helpers for testing and benchmarking
+// schemes persisted with the versioned db package
+package migrationtest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+// BenchmarkScheme measures how long PersistScheme takes to migrate
+// db from scratch using scheme. The recorded version is reset to zero
+// between iterations, so the OnCreate of the scheme must be re-runnable
+// against the same database.
+func BenchmarkScheme(b *testing.B, db *sql.DB, scheme version.Scheme) {
+	b.Helper()
+	b.ReportAllocs()
+
+	strategyName := scheme.VersionStrategy()
+	if err := version.ForceVersion(db, strategyName, 0); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := version.PersistScheme(db, scheme); err != nil {
+			b.Fatal(err)
+		}
+
+		b.StopTimer()
+		if err := version.ForceVersion(db, strategyName, 0); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
@@ -0,0 +1,48 @@
+package migrationtest
+
+import (
+	"database/sql"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+// TestScheme is a Scheme built from its fields, meant for tests and
+// examples. Nil callbacks do nothing and succeed
+type TestScheme struct {
+	VersionInt int
+	Strategy   string
+	CreateFn   func(*sql.DB) error
+	UpdateFn   func(*sql.DB, int) error
+	DropFn     func(*sql.DB) error
+}
+
+func (s *TestScheme) Version() version.SchemeVersion {
+	return version.SchemeVersion(s.VersionInt)
+}
+
+func (s *TestScheme) VersionStrategy() string {
+	return s.Strategy
+}
+
+func (s *TestScheme) OnCreate(db *sql.DB) error {
+	if s.CreateFn == nil {
+		return nil
+	}
+	return s.CreateFn(db)
+}
+
+func (s *TestScheme) OnUpdate(db *sql.DB, oldVersion int) error {
+	if s.UpdateFn == nil {
+		return nil
+	}
+	return s.UpdateFn(db, oldVersion)
+}
+
+// OnDrop removes what the scheme created by calling DropFn, making
+// TestScheme a version.DroppableScheme
+func (s *TestScheme) OnDrop(db *sql.DB) error {
+	if s.DropFn == nil {
+		return nil
+	}
+	return s.DropFn(db)
+}
@@ -0,0 +1,58 @@
+package migrationtest
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var _ version.DroppableScheme = (*TestScheme)(nil)
+
+func TestTestSchemeDefaults(t *testing.T) {
+	s := &TestScheme{VersionInt: 3, Strategy: "fake"}
+
+	assert.Equal(t, version.SchemeVersion(3), s.Version())
+	assert.Equal(t, "fake", s.VersionStrategy())
+	assert.Nil(t, s.OnCreate(nil), "Nil CreateFn must succeed")
+	assert.Nil(t, s.OnUpdate(nil, 1), "Nil UpdateFn must succeed")
+	assert.Nil(t, s.OnDrop(nil), "Nil DropFn must succeed")
+}
+
+func TestTestSchemeCallbacks(t *testing.T) {
+	someError := errors.New("SomeError")
+	var updatedFrom int
+	s := &TestScheme{
+		CreateFn: func(*sql.DB) error { return someError },
+		UpdateFn: func(_ *sql.DB, old int) error { updatedFrom = old; return nil },
+		DropFn:   func(*sql.DB) error { return someError },
+	}
+
+	assert.Equal(t, someError, s.OnCreate(nil), "CreateFn error must be passed out")
+	assert.Nil(t, s.OnUpdate(nil, 2))
+	assert.Equal(t, 2, updatedFrom, "UpdateFn must receive the old version")
+	assert.Equal(t, someError, s.OnDrop(nil), "DropFn error must be passed out")
+}
+
+func TestTestSchemeDropScheme(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	strategy := memoryStrategy{db: 2}
+	registry := version.NewRegistry()
+	registry.Register("memory", strategy)
+
+	dropped := false
+	s := &TestScheme{VersionInt: 2, Strategy: "memory", DropFn: func(*sql.DB) error { dropped = true; return nil }}
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	assert.Nil(t, registry.DropScheme(db, s), "DropScheme must accept a TestScheme")
+	assert.True(t, dropped, "DropFn must be called")
+	assert.Equal(t, 0, strategy[db], "The version must be reset")
+}
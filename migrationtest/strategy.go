@@ -0,0 +1,36 @@
+package migrationtest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+// VerifyStrategy runs the integration checks every strategy must pass
+// against db, a real database not versioned yet: the version starts at
+// zero and reads back each version set, including lower ones as set by
+// ForceVersion
+func VerifyStrategy(t testing.TB, db *sql.DB, strategy version.Strategy) {
+	t.Helper()
+
+	v, err := strategy.Version(db)
+	if err != nil {
+		t.Fatalf("reading the initial version: %v", err)
+	}
+	if v != 0 {
+		t.Fatalf("initial version is %d, want 0", v)
+	}
+
+	for _, want := range []int{1, 2, 1} {
+		if err = strategy.SetVersion(db, want); err != nil {
+			t.Fatalf("setting version %d: %v", want, err)
+		}
+		if v, err = strategy.Version(db); err != nil {
+			t.Fatalf("reading version %d: %v", want, err)
+		}
+		if v != want {
+			t.Fatalf("version is %d, want %d", v, want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package migrationtest
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type memoryStrategy map[*sql.DB]int
+
+func (s memoryStrategy) Version(db *sql.DB) (int, error) {
+	return s[db], nil
+}
+
+func (s memoryStrategy) SetVersion(db *sql.DB, version int) error {
+	s[db] = version
+	return nil
+}
+
+func TestVerifyStrategy(t *testing.T) {
+	VerifyStrategy(t, new(sql.DB), make(memoryStrategy))
+}
@@ -0,0 +1,115 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ErrNonMonotonicVersion is returned by strategies created by
+// NewMonotonicStrategy when a version is recorded that does not follow
+// the current one
+type ErrNonMonotonicVersion struct {
+	Current   int
+	Attempted int
+}
+
+func (e ErrNonMonotonicVersion) Error() string {
+	return fmt.Sprintf("versioned db: version %d does not follow current version %d", e.Attempted, e.Current)
+}
+
+// NewMonotonicStrategy wraps inner so SetVersion only accepts the
+// version following the current one. Databases at version zero may be
+// set to any version, as a scheme creation records its version at
+// once. Creations, downgrades, ForceVersion and DropScheme bypass the
+// check, also through other wrapping strategies. The returned strategy
+// implements TxStrategy when inner does
+func NewMonotonicStrategy(inner Strategy) Strategy {
+	s := &monotonicStrategy{inner: inner}
+	if inner, ok := inner.(TxStrategy); ok {
+		return &monotonicTxStrategy{monotonicStrategy: s, txInner: inner}
+	}
+	return s
+}
+
+type monotonicStrategy struct {
+	inner Strategy
+}
+
+// monotonicTxStrategy is the monotonicStrategy of a TxStrategy
+type monotonicTxStrategy struct {
+	*monotonicStrategy
+	txInner TxStrategy
+}
+
+type forcedVersionKey struct{}
+
+// withForcedVersion returns ctx asking the strategies guarding
+// SetVersion to record the version as is
+func withForcedVersion(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcedVersionKey{}, true)
+}
+
+func versionForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcedVersionKey{}).(bool)
+	return forced
+}
+
+func (s *monotonicStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *monotonicStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *monotonicStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	return strategyVersion(ctx, s.inner, db)
+}
+
+func (s *monotonicStrategy) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	if !versionForced(ctx) {
+		current, err := strategyVersion(ctx, s.inner, db)
+		if err != nil {
+			return err
+		}
+		if err = checkMonotonic(current, version); err != nil {
+			return err
+		}
+	}
+	return strategySetVersion(ctx, s.inner, db, version)
+}
+
+func (s *monotonicTxStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.versionTx(context.Background(), tx)
+}
+
+func (s *monotonicTxStrategy) SetVersionTx(tx *sql.Tx, version int) error {
+	return s.setVersionTx(context.Background(), tx, version)
+}
+
+func (s *monotonicTxStrategy) versionTx(ctx context.Context, tx *sql.Tx) (int, error) {
+	return strategyVersionTx(ctx, s.txInner, tx)
+}
+
+func (s *monotonicTxStrategy) setVersionTx(ctx context.Context, tx *sql.Tx, version int) error {
+	if !versionForced(ctx) {
+		current, err := strategyVersionTx(ctx, s.txInner, tx)
+		if err != nil {
+			return err
+		}
+		if err = checkMonotonic(current, version); err != nil {
+			return err
+		}
+	}
+	return strategySetVersionTx(ctx, s.txInner, tx, version)
+}
+
+// checkMonotonic returns ErrNonMonotonicVersion unless version may be
+// recorded over current
+func checkMonotonic(current, version int) error {
+	if current != 0 && version != current+1 {
+		return ErrNonMonotonicVersion{Current: current, Attempted: version}
+	}
+	return nil
+}
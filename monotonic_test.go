@@ -0,0 +1,171 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMonotonicStrategyAcceptsNextVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(5, nil).
+		On("SetVersion", db, 6).Return(nil)
+
+	err := NewMonotonicStrategy(strategy).SetVersion(db, 6)
+	assert.Nil(t, err, "The following version must be accepted")
+	strategy.AssertExpectations(t)
+}
+
+func TestMonotonicStrategyRejectsSkippedVersions(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(5, nil)
+
+	err := NewMonotonicStrategy(strategy).SetVersion(db, 100)
+	assert.Equal(t, ErrNonMonotonicVersion{Current: 5, Attempted: 100}, err)
+	strategy.AssertNotCalled(t, "SetVersion", db, 100)
+}
+
+func TestMonotonicStrategyTx(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, ok := NewMonotonicStrategy(strategy).(TxStrategy)
+	assert.False(t, ok, "TxStrategy is not implemented by the wrapped strategy")
+	s, ok := NewMonotonicStrategy(NewTableStrategy("")).(TxStrategy)
+	assert.True(t, ok, "TxStrategy of the wrapped strategy must be forwarded")
+
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	assert.Equal(t, ErrNonMonotonicVersion{Current: 1, Attempted: 3}, s.SetVersionTx(tx, 3))
+	assert.Nil(t, tx.Rollback())
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestMonotonicStrategyAcceptsCreation(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 3).Return(nil)
+
+	err := NewMonotonicStrategy(strategy).SetVersion(db, 3)
+	assert.Nil(t, err, "Empty databases may be set to any version")
+}
+
+func TestForceVersionBypassesMonotonicStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("monotonic", NewMonotonicStrategy(strategy))
+	strategy.On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := ForceVersion(db, "monotonic", 1)
+	assert.Nil(t, err, "ForceVersion must bypass the monotonic check")
+	strategy.AssertExpectations(t)
+	strategy.AssertNotCalled(t, "Version", db)
+}
+
+func TestForceVersionBypassesWrappedMonotonicStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("monotonic", NewTimeoutStrategy(NewMonotonicStrategy(strategy), time.Second, time.Second))
+	strategy.On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := ForceVersion(db, "monotonic", 1)
+	assert.Nil(t, err, "ForceVersion must bypass the monotonic check through wrappers")
+	strategy.AssertExpectations(t)
+	strategy.AssertNotCalled(t, "Version", db)
+}
+
+func TestMonotonicStrategyAcceptsDowngrade(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("monotonic", NewMonotonicStrategy(strategy))
+	downgrading := new(downgradeSchemeMock)
+
+	strategy.
+		On("Version", db).Return(3, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	downgrading.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("monotonic").
+		On("OnDowngrade", db, 2).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, downgrading)
+	assert.Nil(t, err, "Downgrades must bypass the monotonic check")
+	strategy.AssertExpectations(t)
+	downgrading.AssertExpectations(t)
+}
+
+func TestMonotonicStrategyAcceptsIdempotentRecreation(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("monotonic", NewMonotonicStrategy(strategy))
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("monotonic").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, NewIdempotentScheme(scheme, 1))
+	assert.Nil(t, err, "Recreations must bypass the monotonic check")
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+}
+
+func TestDropSchemeBypassesMonotonicStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("monotonic", NewMonotonicStrategy(strategy))
+	droppable := new(droppableSchemeMock)
+	strategy.On("SetVersion", db, 0).Return(nil)
+	droppable.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("monotonic").
+		On("OnDrop", db).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := DropScheme(db, droppable)
+	assert.Nil(t, err, "DropScheme must bypass the monotonic check")
+	strategy.AssertExpectations(t)
+	strategy.AssertNotCalled(t, "Version", db)
+}
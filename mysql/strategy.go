@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+const defaultTable = "schema_version"
+
+// NewMySQLStrategy returns a strategy storing the version in tableName,
+// schema_version when empty, of a MySQL 5.7+ or MariaDB database. The
+// InnoDB table is created by the first Version or SetVersion call and
+// holds a single row, initialized to version zero, that SetVersion
+// upserts with INSERT ... ON DUPLICATE KEY UPDATE.
+//
+// The strategy implements version.TxStrategy. VersionTx reads the row
+// with SELECT ... FOR UPDATE, the row lock being held until the
+// transaction ends. PersistScheme reads the version again with it once
+// the migration transaction is opened, as PersistSchemeTx does, so
+// concurrent migrations are serialized on the row. As MySQL commits the running transaction
+// on CREATE TABLE, VersionTx and SetVersionTx do not create the table:
+// it must be created beforehand, by WarmUp or by a call outside of any
+// transaction. Version reads the row without locking it
+func NewMySQLStrategy(tableName string) version.Strategy {
+	if tableName == "" {
+		tableName = defaultTable
+	}
+	return &mysqlStrategy{table: tableName}
+}
+
+type mysqlStrategy struct {
+	table string
+}
+
+// conn is implemented by *sql.DB and *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *mysqlStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *mysqlStrategy) SetVersion(db *sql.DB, v int) error {
+	return s.SetVersionContext(context.Background(), db, v)
+}
+
+func (s *mysqlStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	if err := s.createTable(ctx, db); err != nil {
+		return 0, err
+	}
+	return s.version(ctx, db, "")
+}
+
+func (s *mysqlStrategy) SetVersionContext(ctx context.Context, db *sql.DB, v int) error {
+	if err := s.createTable(ctx, db); err != nil {
+		return err
+	}
+	return s.setVersion(ctx, db, v)
+}
+
+// VersionTx is like Version but reads within tx, locking the row until
+// tx ends
+func (s *mysqlStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.version(context.Background(), tx, " FOR UPDATE")
+}
+
+// SetVersionTx is like SetVersion but writes within tx
+func (s *mysqlStrategy) SetVersionTx(tx *sql.Tx, v int) error {
+	return s.setVersion(context.Background(), tx, v)
+}
+
+// CreateVersionTable implements version.TableCreator
+func (s *mysqlStrategy) CreateVersionTable(db *sql.DB) error {
+	return s.createTable(context.Background(), db)
+}
+
+// VersionTableExists implements version.TableCreator, looking the
+// table up in the current database
+func (s *mysqlStrategy) VersionTableExists(db *sql.DB) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM information_schema.tables "+
+		"WHERE table_schema = DATABASE() AND table_name = ?)", s.table).Scan(&exists)
+	return exists, err
+}
+
+func (s *mysqlStrategy) version(ctx context.Context, c conn, lock string) (int, error) {
+	var v int
+	err := c.QueryRowContext(ctx, "SELECT version FROM "+s.table+" WHERE id = 1"+lock).Scan(&v)
+	if err == sql.ErrNoRows {
+		_, err = c.ExecContext(ctx, "INSERT IGNORE INTO "+s.table+" (id, version) VALUES (1, 0)")
+		return 0, err
+	}
+	return v, err
+}
+
+func (s *mysqlStrategy) setVersion(ctx context.Context, c conn, v int) error {
+	_, err := c.ExecContext(ctx, "INSERT INTO "+s.table+" (id, version) VALUES (1, ?) "+
+		"ON DUPLICATE KEY UPDATE version = VALUES(version)", v)
+	return err
+}
+
+func (s *mysqlStrategy) createTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+
+		" (id TINYINT UNSIGNED NOT NULL PRIMARY KEY, version INT NOT NULL) ENGINE=InnoDB")
+	return err
+}
@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var (
+	_ version.TxStrategy   = (*mysqlStrategy)(nil)
+	_ version.TableCreator = (*mysqlStrategy)(nil)
+)
+
+func TestVersionCreatesTableAndInitializes(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS versions .* ENGINE=InnoDB").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT version FROM versions WHERE id = 1$").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT IGNORE INTO versions").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	v, err := NewMySQLStrategy("versions").Version(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, v, "Absent version must be initialized to zero")
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+
+	v, err := NewMySQLStrategy("").Version(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, v)
+}
+
+func TestSetVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("INSERT INTO schema_version .* ON DUPLICATE KEY UPDATE").WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.Nil(t, NewMySQLStrategy("").SetVersion(db, 5))
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestCreateTableError(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnError(sqlmock.ErrCancelled)
+
+	_, err = NewMySQLStrategy("").Version(db)
+	assert.Equal(t, sqlmock.ErrCancelled, err)
+}
+
+func TestVersionTxLocksWithoutCreatingTable(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version WHERE id = 1 FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectExec("INSERT INTO schema_version .* ON DUPLICATE KEY UPDATE").WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	dbMock.ExpectCommit()
+
+	s := NewMySQLStrategy("").(version.TxStrategy)
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	v, err := s.VersionTx(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+	assert.Nil(t, s.SetVersionTx(tx, 3))
+	assert.Nil(t, tx.Commit())
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("No table must be created within the transaction. Err %q", err)
+	}
+}
+
+func TestTableCreator(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectQuery("FROM information_schema.tables WHERE table_schema = DATABASE\\(\\) AND table_name = \\?").
+		WithArgs("schema_version").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	creator := NewMySQLStrategy("").(version.TableCreator)
+	exists, err := creator.VersionTableExists(db)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+	assert.Nil(t, creator.CreateVersionTable(db))
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemeLocksVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	r := version.NewRegistry()
+	r.Register("mysql", NewMySQLStrategy(""))
+	scheme := version.NewSQLScheme(2, "mysql", "", map[int]string{1: "ALTER TABLE users ADD email text"})
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT version FROM schema_version WHERE id = 1$").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version WHERE id = 1 FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("INSERT INTO schema_version \\(id, version\\) VALUES \\(1, \\?\\)").WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	res, err := r.PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, version.ActionUpdate, res.Action)
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("The version must be locked within the migration transaction. Err %q", err)
+	}
+}
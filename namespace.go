@@ -0,0 +1,35 @@
+package version
+
+import "errors"
+
+// Errors returned by NewNamespacedStrategy, to be checked with
+// errors.Is
+var (
+	ErrNamespaceNotSupported = errors.New("versioned db: strategy does not support namespaces")
+	ErrEmptyNamespace        = errors.New("versioned db: namespace is empty")
+)
+
+// namespacedStrategy is implemented by strategies able to store the
+// versions of several namespaces side by side
+type namespacedStrategy interface {
+	withNamespace(namespace string) Strategy
+}
+
+// NewNamespacedStrategy returns inner keeping the version of namespace
+// apart from those of other namespaces, such as the tenants sharing a
+// database. Strategies created by NewTableStrategy gain a namespace
+// column in their tables, which must then not be shared with strategies
+// without namespace. Strategies created by BackendStrategy prefix their
+// name with "namespace/". It returns ErrNamespaceNotSupported for
+// other strategies, such as those of the postgres, mysql and
+// cockroachdb packages, and ErrEmptyNamespace for an empty namespace
+func NewNamespacedStrategy(inner Strategy, namespace string) (Strategy, error) {
+	s, ok := inner.(namespacedStrategy)
+	if !ok {
+		return nil, ErrNamespaceNotSupported
+	}
+	if namespace == "" {
+		return nil, ErrEmptyNamespace
+	}
+	return s.withNamespace(namespace), nil
+}
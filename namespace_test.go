@@ -0,0 +1,92 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestNamespacedTableStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	tenant, err := NewNamespacedStrategy(NewTableStrategy("versions"), "acme")
+	assert.Nil(t, err, "NewNamespacedStrategy must not return error")
+
+	expectTableLookup("versions_history", false)
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS versions \\(namespace text NOT NULL, version integer NOT NULL, " +
+		"PRIMARY KEY \\(namespace\\)\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS versions_history \\(id serial PRIMARY KEY, namespace text NOT NULL").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM versions WHERE namespace = \\$1").WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO versions \\(version, namespace\\) VALUES \\(\\$1, \\$2\\)").WithArgs(1, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO versions_history \\(version, action, applied_at, duration, description, namespace\\) "+
+		"VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6\\)").
+		WithArgs(1, "create", sqlmock.AnyArg(), sqlmock.AnyArg(), "", "acme").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	err = tenant.SetVersion(db, 1)
+	assert.Nil(t, err, "SetVersion must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestNamespacedTableStrategyUpdate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	tenant, err := NewNamespacedStrategy(NewTableStrategy(""), "acme")
+	assert.Nil(t, err, "NewNamespacedStrategy must not return error")
+
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version WHERE namespace = \\$1").WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version = \\$1 WHERE namespace = \\$2").WithArgs(2, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").
+		WithArgs(2, "update", sqlmock.AnyArg(), sqlmock.AnyArg(), "", "acme").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	assert.Nil(t, tenant.SetVersion(db, 2), "SetVersion must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestNamespacedBackendStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	backend := new(backendMock)
+	kv, err := BackendStrategy("kv", backend)
+	assert.Nil(t, err, "BackendStrategy must not return error")
+	tenant, err := NewNamespacedStrategy(kv, "acme")
+	assert.Nil(t, err, "NewNamespacedStrategy must not return error")
+	Register("kv", tenant)
+
+	backend.On("ReadVersion", mock.Anything, "acme/kv").Return(3, nil)
+
+	v, err := CurrentVersion(db, "kv")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v, "Version must be read under the namespaced key")
+	backend.AssertExpectations(t)
+}
+
+func TestNamespacedStrategyUnsupported(t *testing.T) {
+	_, err := NewNamespacedStrategy(new(versionStrategyMock), "acme")
+	assert.True(t, errors.Is(err, ErrNamespaceNotSupported), "Unsupported strategies must be reported")
+	_, err = NewNamespacedStrategy(NewTableStrategy(""), "")
+	assert.True(t, errors.Is(err, ErrEmptyNamespace), "Empty namespaces must be reported")
+}
@@ -0,0 +1,57 @@
+package version
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// NDJSONListener is an EventListener writing every MigrationEvent as a
+// JSON object on its own line, for log collectors. As EventListener
+// cannot fail, write errors are dropped
+type NDJSONListener struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewNDJSONListener returns a listener writing the events to w, which
+// may be shared by concurrent migrations
+func NewNDJSONListener(w io.Writer) *NDJSONListener {
+	return &NDJSONListener{w: w}
+}
+
+type ndjsonRecord struct {
+	Timestamp   time.Time          `json:"timestamp"`
+	EventType   MigrationEventType `json:"event_type"`
+	SchemeName  string             `json:"scheme_name"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	DurationMS  int64              `json:"duration_ms"`
+	Error       *string            `json:"error"`
+}
+
+// OnMigrationEvent writes event to the writer of l. The error field is
+// null unless the migration failed
+func (l *NDJSONListener) OnMigrationEvent(event MigrationEvent) {
+	record := ndjsonRecord{
+		Timestamp:   event.Timestamp,
+		EventType:   event.EventType,
+		SchemeName:  event.SchemeName,
+		FromVersion: event.OldVersion,
+		ToVersion:   event.NewVersion,
+		DurationMS:  event.Duration.Milliseconds(),
+	}
+	if event.Error != nil {
+		msg := event.Error.Error()
+		record.Error = &msg
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(line, '\n'))
+}
@@ -0,0 +1,48 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSONListener(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var buf bytes.Buffer
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme, WithEventListener(NewNDJSONListener(&buf)))
+	assert.NotNil(t, err, "OnUpdate error must be returned")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2, "One line must be written per event") {
+		var started, failed map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(lines[0]), &started))
+		assert.Nil(t, json.Unmarshal([]byte(lines[1]), &failed))
+
+		assert.Equal(t, "started", started["event_type"])
+		assert.Nil(t, started["error"], "error must be null unless the migration failed")
+		assert.Equal(t, "failed", failed["event_type"])
+		assert.Contains(t, failed, "scheme_name")
+		assert.Equal(t, float64(1), failed["from_version"])
+		assert.Equal(t, float64(2), failed["to_version"])
+		assert.Contains(t, failed, "duration_ms")
+		assert.Contains(t, failed, "timestamp")
+		assert.Contains(t, failed["error"], someError.Error())
+	}
+}
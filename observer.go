@@ -0,0 +1,34 @@
+package version
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Observer is notified of every migration PersistScheme runs, to feed
+// metrics or tracing systems. action is one of "create", "update" or
+// "downgrade"; databases left untouched are not reported
+type Observer interface {
+	OnMigrationStart(ctx context.Context, strategy, action string, fromVersion, toVersion int)
+	OnMigrationEnd(ctx context.Context, strategy, action string, fromVersion, toVersion int, dur time.Duration, err error)
+}
+
+var (
+	observerMu sync.RWMutex
+	observer   Observer
+)
+
+// SetObserver makes the package notify o of every migration. A nil o
+// disables the notifications
+func SetObserver(o Observer) {
+	observerMu.Lock()
+	defer observerMu.Unlock()
+	observer = o
+}
+
+func currentObserver() Observer {
+	observerMu.RLock()
+	defer observerMu.RUnlock()
+	return observer
+}
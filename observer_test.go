@@ -0,0 +1,69 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type observerMock struct {
+	mock.Mock
+}
+
+func (m *observerMock) OnMigrationStart(ctx context.Context, strategy, action string, fromVersion, toVersion int) {
+	m.Called(strategy, action, fromVersion, toVersion)
+}
+
+func (m *observerMock) OnMigrationEnd(ctx context.Context, strategy, action string, fromVersion, toVersion int, dur time.Duration, err error) {
+	m.Called(strategy, action, fromVersion, toVersion, err)
+}
+
+func TestSetObserver(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	obs := new(observerMock)
+	SetObserver(obs)
+	defer SetObserver(nil)
+
+	strategy.On("Version", db).Return(0, nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(someError)
+	dbMock.ExpectRollback()
+
+	obs.
+		On("OnMigrationStart", "fake", "create", 0, 1).
+		On("OnMigrationEnd", "fake", "create", 0, 1, mock.MatchedBy(func(err error) bool {
+			return errors.Is(err, someError)
+		}))
+
+	_, err := PersistScheme(db, scheme)
+	assert.NotNil(t, err)
+	obs.AssertExpectations(t)
+}
+
+func TestObserverNotCalledWhenUpToDate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	obs := new(observerMock)
+	SetObserver(obs)
+	defer SetObserver(nil)
+
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err)
+	obs.AssertNotCalled(t, "OnMigrationStart", "fake", "noop", 1, 1)
+}
@@ -0,0 +1,114 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Option configures how PersistScheme migrates a database
+type Option func(*options)
+
+type options struct {
+	transitionHook   TransitionHook
+	migrationTimeout time.Duration
+	// detached keeps the cancellation of the caller context from
+	// interrupting a statement, it is only checked between steps
+	detached     bool
+	parallelism  int
+	pool         *poolConfig
+	sqlTrace     *SQLTrace
+	info         MigrationInfo
+	futurePolicy FuturePolicy
+	listeners    []EventListener
+	// targetVersion bounds the updates, zero meaning the scheme version
+	targetVersion int
+	// singleStep stops the updates after one version
+	singleStep bool
+	retry      *retryConfig
+	// txOnly requires every step to run within the migration
+	// transaction
+	txOnly bool
+	// autoRollback undoes the steps done on db when a later one fails
+	autoRollback bool
+}
+
+type poolConfig struct {
+	maxOpen, maxIdle int
+}
+
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// TransitionHook is called on every version transition, after the
+// scheme was created or updated and before the new version is recorded.
+// Returning an error rolls the migration back
+type TransitionHook func(db *sql.DB, from, to int) error
+
+// WithTransitionHook makes PersistScheme call h on every version
+// transition. Hooks passed by several options are called in order
+func WithTransitionHook(h TransitionHook) Option {
+	return func(o *options) {
+		if o.transitionHook == nil {
+			o.transitionHook = h
+		} else {
+			o.transitionHook = CombineTransitionHooks(o.transitionHook, h)
+		}
+	}
+}
+
+// CombineTransitionHooks returns a hook calling each of hooks in order,
+// stopping at the first one returning an error
+func CombineTransitionHooks(hooks ...TransitionHook) TransitionHook {
+	return func(db *sql.DB, from, to int) error {
+		for _, h := range hooks {
+			if h == nil {
+				continue
+			}
+			if err := h(db, from, to); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithMigrationTimeout bounds the database operations of the migration
+// by d, independently of the deadline of the context passed to
+// PersistSchemeContext. The cancellation of that context is still
+// honoured between the migration steps
+func WithMigrationTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.migrationTimeout = d
+	}
+}
+
+// migrationContext returns the context used for the database
+// operations and callbacks of the migration, and the function ending
+// the migration, which releases what strategies held for it
+func (o *options) migrationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	info := o.info
+	info.StartedAt = time.Now()
+	m := new(migration)
+	ctx = context.WithValue(ctx, migrationInfoKey{}, info)
+	ctx = context.WithValue(ctx, migrationKey{}, m)
+	if o.sqlTrace != nil {
+		ctx = context.WithValue(ctx, sqlTraceKey{}, o.sqlTrace)
+	}
+
+	cancel := func() {}
+	if o.migrationTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), o.migrationTimeout)
+	} else if o.detached {
+		ctx = context.WithoutCancel(ctx)
+	}
+	return ctx, func() {
+		cancel()
+		m.release()
+	}
+}
@@ -0,0 +1,141 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTransitionHook(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var calls [][2]int
+	hook := func(hookDB *sql.DB, from, to int) error {
+		assert.Equal(t, db, hookDB, "Hook must receive the migrated db")
+		calls = append(calls, [2]int{from, to})
+		return nil
+	}
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme, WithTransitionHook(hook), WithTransitionHook(hook))
+	assert.Nil(t, err, "PersistScheme must not return error on update")
+	assert.Equal(t, [][2]int{{1, 2}, {1, 2}}, calls, "Every hook must be called once per transition")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+}
+
+func TestTransitionHookError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	hook := func(*sql.DB, int, int) error { return someError }
+
+	strategy.
+		On("Version", db).Return(0, nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme, WithTransitionHook(hook))
+	assert.Equal(t, someError, err, "Hook error must be passed out")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestCombineTransitionHooksStopsOnError(t *testing.T) {
+	var called []string
+	first := func(*sql.DB, int, int) error { called = append(called, "first"); return someError }
+	second := func(*sql.DB, int, int) error { called = append(called, "second"); return nil }
+
+	err := CombineTransitionHooks(first, nil, second)(nil, 0, 1)
+	assert.Equal(t, someError, err, "First error must be returned")
+	assert.Equal(t, []string{"first"}, called, "Hooks after a failing one must not run")
+}
+
+func TestMigrationTimeoutOutlivesParentDeadline(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	ctxStrategy := new(contextStrategyMock)
+	Register("ctx", ctxStrategy)
+
+	var migrationDeadline time.Time
+	ctxStrategy.
+		On("VersionContext", mock.Anything, db).Return(0, nil).
+		Run(func(args mock.Arguments) {
+			migrationDeadline, _ = args.Get(0).(context.Context).Deadline()
+		}).
+		On("SetVersionContext", mock.Anything, db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("ctx").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistSchemeContext(parent, db, scheme, WithMigrationTimeout(2*time.Hour))
+	assert.Nil(t, err, "PersistSchemeContext must not return error on create")
+
+	parentDeadline, _ := parent.Deadline()
+	assert.True(t, migrationDeadline.After(parentDeadline), "Migration deadline must not be bound to the parent one")
+
+	ctxStrategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+}
+
+func TestMigrationTimeoutChecksParentBetweenSteps(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		Run(func(mock.Arguments) { cancel() })
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+	dbMock.ExpectRollback()
+
+	_, err := PersistSchemeContext(parent, db, scheme, WithMigrationTimeout(time.Minute))
+	assert.Equal(t, context.Canceled, err, "Parent cancellation must abort the migration")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
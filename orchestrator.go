@@ -0,0 +1,158 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DatabaseResult is the outcome of migrating one of the databases of
+// an Orchestrator. DSN is only set for databases added by AddDSN, whose
+// DB is closed once MigrateAll returns
+type DatabaseResult struct {
+	MigrationResult
+	DB     *sql.DB
+	DSN    string
+	Scheme Scheme
+	Err    error
+}
+
+// Orchestrator persists schemes across many databases, such as in
+// database per tenant architectures
+type Orchestrator struct {
+	// OpenTimeout bounds the time spent opening and pinging each of
+	// the databases added by AddDSN, no bound is applied when zero
+	OpenTimeout time.Duration
+
+	mu      sync.Mutex
+	targets []orchestratorTarget
+}
+
+type orchestratorTarget struct {
+	db     *sql.DB
+	driver string
+	dsn    string
+	scheme Scheme
+}
+
+// AddDatabase registers db to be migrated to scheme by MigrateAll
+func (o *Orchestrator) AddDatabase(db *sql.DB, scheme Scheme) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.targets = append(o.targets, orchestratorTarget{db: db, scheme: scheme})
+}
+
+// AddDSN registers the database at dsn to be migrated to scheme by
+// MigrateAll. The connection is only opened by MigrateAll, with driver,
+// and closed once the database is migrated
+func (o *Orchestrator) AddDSN(driver, dsn string, scheme Scheme) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.targets = append(o.targets, orchestratorTarget{driver: driver, dsn: dsn, scheme: scheme})
+}
+
+// WithConnectionPool sets the maximum number of open and idle
+// connections of the databases an Orchestrator opens from a DSN
+func WithConnectionPool(maxOpen, maxIdle int) Option {
+	return func(o *options) {
+		o.pool = &poolConfig{maxOpen: maxOpen, maxIdle: maxIdle}
+	}
+}
+
+// WithParallelism limits to n the number of databases an Orchestrator
+// migrates at the same time. Databases are migrated one at a time when
+// n is lower than one
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.parallelism = n
+	}
+}
+
+// MigrateAll persists the scheme of each added database, returning one
+// result per database in the order they were added. A failing database
+// does not stop the others; an error is returned if any failed.
+// Databases not started when ctx is cancelled are reported with the
+// context error, which is returned too. opts are passed to every
+// PersistSchemeContext call
+func (o *Orchestrator) MigrateAll(ctx context.Context, opts ...Option) ([]DatabaseResult, error) {
+	o.mu.Lock()
+	targets := append([]orchestratorTarget{}, o.targets...)
+	o.mu.Unlock()
+
+	config := newOptions(opts)
+	parallelism := config.parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]DatabaseResult, len(targets))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		results[i] = DatabaseResult{DB: target.db, DSN: target.dsn, Scheme: target.scheme}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(target orchestratorTarget, res *DatabaseResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if res.DB == nil {
+				if res.DB, res.Err = o.open(ctx, target, config.pool); res.Err != nil {
+					return
+				}
+				defer res.DB.Close()
+			}
+			res.MigrationResult, res.Err = PersistSchemeContext(ctx, res.DB, res.Scheme, opts...)
+		}(target, &results[i])
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("versioned db: %d of %d databases failed to migrate", failed, len(results))
+	}
+	return results, nil
+}
+
+func (o *Orchestrator) open(ctx context.Context, target orchestratorTarget, pool *poolConfig) (*sql.DB, error) {
+	db, err := sql.Open(target.driver, target.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
+		db.SetMaxOpenConns(pool.maxOpen)
+		db.SetMaxIdleConns(pool.maxIdle)
+	}
+
+	if o.OpenTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.OpenTimeout)
+		defer cancel()
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,123 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestOrchestratorMigrateAll(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	failing, failingMock, _ := sqlmock.New()
+	defer failing.Close()
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil).
+		On("Version", failing).Return(0, nil)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil).
+		On("OnCreate", failing).Return(someError)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+	failingMock.ExpectBegin()
+	failingMock.ExpectRollback()
+
+	o := new(Orchestrator)
+	o.AddDatabase(db, scheme)
+	o.AddDatabase(failing, scheme)
+
+	results, err := o.MigrateAll(context.Background(), WithParallelism(2))
+	assert.NotNil(t, err, "A failing database must be reported")
+	assert.Len(t, results, 2)
+	assert.Equal(t, db, results[0].DB)
+	assert.Nil(t, results[0].Err, "Successful database must not report error")
+	assert.Equal(t, ActionCreate, results[0].Action, "Migration result must be reported")
+	assert.Equal(t, failing, results[1].DB)
+	assert.True(t, errors.Is(results[1].Err, someError), "Failing database must report its error")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+	if err = failingMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestOrchestratorMigrateAllCancelled(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	o := new(Orchestrator)
+	o.AddDatabase(db, scheme)
+
+	results, err := o.MigrateAll(ctx)
+	assert.Equal(t, context.Canceled, err, "Cancellation must be returned")
+	assert.Equal(t, context.Canceled, results[0].Err, "Skipped databases must report the cancellation")
+	scheme.AssertExpectations(t)
+}
+
+func TestOrchestratorAddDSN(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, tenantMock, err := sqlmock.NewWithDSN("orchestrator_tenant")
+	assert.Nil(t, err)
+
+	strategy.
+		On("Version", mock.Anything).Return(0, nil).
+		On("SetVersion", mock.Anything, 1).Return(nil)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", mock.Anything).Return(nil)
+
+	tenantMock.ExpectBegin()
+	tenantMock.ExpectCommit()
+	tenantMock.ExpectClose()
+
+	o := &Orchestrator{OpenTimeout: time.Second}
+	o.AddDSN("sqlmock", "orchestrator_tenant", scheme)
+
+	results, err := o.MigrateAll(context.Background(), WithConnectionPool(2, 1))
+	assert.Nil(t, err, "MigrateAll must not return error")
+	assert.Equal(t, "orchestrator_tenant", results[0].DSN)
+	assert.NotNil(t, results[0].DB, "Opened database must be reported")
+	assert.Nil(t, results[0].Err)
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	if err = tenantMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestOrchestratorAddDSNOpenError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	o := new(Orchestrator)
+	o.AddDSN("not_registered", "dsn", scheme)
+
+	results, err := o.MigrateAll(context.Background())
+	assert.NotNil(t, err, "A failing database must be reported")
+	assert.NotNil(t, results[0].Err, "Open error must be reported")
+	scheme.AssertExpectations(t)
+}
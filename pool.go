@@ -0,0 +1,65 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// VersionedPool keeps one *sql.DB per schema version, so that several
+// versions of an application can run against the same database during
+// a blue green deployment, each one seeing the schema it expects.
+// Connections are opened lazily on the first request for a version
+type VersionedPool struct {
+	open func(version int) (*sql.DB, error)
+
+	mu  sync.Mutex
+	dbs map[int]*sql.DB
+}
+
+// NewVersionedPool returns a pool opening the connections for a
+// schema version with open
+func NewVersionedPool(open func(version int) (*sql.DB, error)) *VersionedPool {
+	return &VersionedPool{open: open, dbs: make(map[int]*sql.DB)}
+}
+
+// For returns the connection for the schema version, opening it if it is
+// the first request for version
+func (p *VersionedPool) For(version int) (*sql.DB, error) {
+	if version < 1 {
+		return nil, ErrVersionBelowOne
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.dbs == nil {
+		return nil, errors.New("versioned db: pool is closed")
+	}
+	if db, ok := p.dbs[version]; ok {
+		return db, nil
+	}
+
+	db, err := p.open(version)
+	if err != nil {
+		return nil, err
+	}
+	p.dbs[version] = db
+	return db, nil
+}
+
+// Close closes every connection opened by the pool. The pool must not
+// be used afterwards
+func (p *VersionedPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for _, db := range p.dbs {
+		if closeErr := db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	p.dbs = nil
+	return err
+}
@@ -0,0 +1,59 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestVersionedPoolOpensLazily(t *testing.T) {
+	var opened []int
+	pool := NewVersionedPool(func(version int) (*sql.DB, error) {
+		opened = append(opened, version)
+		db, _, err := sqlmock.New()
+		return db, err
+	})
+	defer pool.Close()
+
+	first, err := pool.For(2)
+	assert.Nil(t, err, "For must not return error")
+	again, err := pool.For(2)
+	assert.Nil(t, err, "For must not return error")
+	other, err := pool.For(3)
+	assert.Nil(t, err, "For must not return error")
+
+	assert.Equal(t, first, again, "Connections must be reused per version")
+	assert.NotEqual(t, first, other, "Each version must get its own connection")
+	assert.Equal(t, []int{2, 3}, opened, "Connections must be opened once per version")
+}
+
+func TestVersionedPoolOpenError(t *testing.T) {
+	pool := NewVersionedPool(func(int) (*sql.DB, error) { return nil, someError })
+
+	_, err := pool.For(1)
+	assert.Equal(t, someError, err, "Open error must be passed out")
+}
+
+func TestVersionedPoolInvalidVersion(t *testing.T) {
+	pool := NewVersionedPool(func(int) (*sql.DB, error) {
+		t.Error("Open must not be called for invalid versions")
+		return nil, nil
+	})
+
+	_, err := pool.For(0)
+	assert.True(t, errors.Is(err, ErrVersionBelowOne), "ErrVersionBelowOne must be returned for versions below one")
+}
+
+func TestVersionedPoolClosed(t *testing.T) {
+	pool := NewVersionedPool(func(int) (*sql.DB, error) {
+		db, _, err := sqlmock.New()
+		return db, err
+	})
+	assert.Nil(t, pool.Close(), "Close must not return error")
+
+	_, err := pool.For(1)
+	assert.NotNil(t, err, "A closed pool must return error")
+}
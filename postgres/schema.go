@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+const (
+	tablesQuery = `SELECT table_name FROM information_schema.tables
+WHERE table_schema = $1 AND table_type = 'BASE TABLE'
+ORDER BY table_name`
+
+	columnsQuery = `SELECT table_name, column_name, data_type, is_nullable = 'YES', COALESCE(column_default, '')
+FROM information_schema.columns
+WHERE table_schema = $1
+ORDER BY table_name, ordinal_position`
+
+	indexesQuery = `SELECT t.relname, i.relname, ix.indisunique, a.attname
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = $1
+ORDER BY t.relname, i.relname, k.ord`
+
+	constraintsQuery = `SELECT tc.table_name, tc.constraint_name, tc.constraint_type, kcu.column_name
+FROM information_schema.table_constraints tc
+LEFT JOIN information_schema.key_column_usage kcu
+ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+AND kcu.table_name = tc.table_name
+WHERE tc.table_schema = $1 AND tc.constraint_type <> 'CHECK'
+ORDER BY tc.table_name, tc.constraint_name, kcu.ordinal_position`
+)
+
+// PostgresSchemaInspector inspects a PostgreSQL database using the
+// information_schema and pg_catalog views
+type PostgresSchemaInspector struct {
+	// SchemaName is the PostgreSQL schema to be inspected,
+	// "public" is used when it is empty
+	SchemaName string
+}
+
+// InspectSchema reads tables, columns, indexes and constraints
+// of the inspected PostgreSQL schema
+func (p *PostgresSchemaInspector) InspectSchema(db *sql.DB) (version.Schema, error) {
+	schemaName := p.SchemaName
+	if schemaName == "" {
+		schemaName = "public"
+	}
+
+	var tables []*version.Table
+	byName := make(map[string]*version.Table)
+
+	rows, err := db.Query(tablesQuery, schemaName)
+	if err != nil {
+		return version.Schema{}, err
+	}
+	for rows.Next() {
+		t := new(version.Table)
+		if err = rows.Scan(&t.Name); err != nil {
+			rows.Close()
+			return version.Schema{}, err
+		}
+		tables = append(tables, t)
+		byName[t.Name] = t
+	}
+	if err = closeRows(rows); err != nil {
+		return version.Schema{}, err
+	}
+
+	rows, err = db.Query(columnsQuery, schemaName)
+	if err != nil {
+		return version.Schema{}, err
+	}
+	for rows.Next() {
+		var (
+			table string
+			c     version.Column
+		)
+		if err = rows.Scan(&table, &c.Name, &c.Type, &c.Nullable, &c.Default); err != nil {
+			rows.Close()
+			return version.Schema{}, err
+		}
+		if t, ok := byName[table]; ok {
+			t.Columns = append(t.Columns, c)
+		}
+	}
+	if err = closeRows(rows); err != nil {
+		return version.Schema{}, err
+	}
+
+	rows, err = db.Query(indexesQuery, schemaName)
+	if err != nil {
+		return version.Schema{}, err
+	}
+	for rows.Next() {
+		var (
+			table, index, column string
+			unique               bool
+		)
+		if err = rows.Scan(&table, &index, &unique, &column); err != nil {
+			rows.Close()
+			return version.Schema{}, err
+		}
+		t, ok := byName[table]
+		if !ok {
+			continue
+		}
+		if n := len(t.Indexes); n > 0 && t.Indexes[n-1].Name == index {
+			t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, column)
+		} else {
+			t.Indexes = append(t.Indexes, version.Index{Name: index, Columns: []string{column}, Unique: unique})
+		}
+	}
+	if err = closeRows(rows); err != nil {
+		return version.Schema{}, err
+	}
+
+	rows, err = db.Query(constraintsQuery, schemaName)
+	if err != nil {
+		return version.Schema{}, err
+	}
+	for rows.Next() {
+		var (
+			table, name, kind string
+			column            sql.NullString
+		)
+		if err = rows.Scan(&table, &name, &kind, &column); err != nil {
+			rows.Close()
+			return version.Schema{}, err
+		}
+		t, ok := byName[table]
+		if !ok {
+			continue
+		}
+		n := len(t.Constraints)
+		if n == 0 || t.Constraints[n-1].Name != name {
+			t.Constraints = append(t.Constraints, version.Constraint{Name: name, Type: kind})
+			n++
+		}
+		if column.Valid {
+			t.Constraints[n-1].Columns = append(t.Constraints[n-1].Columns, column.String)
+		}
+	}
+	if err = closeRows(rows); err != nil {
+		return version.Schema{}, err
+	}
+
+	schema := version.Schema{Tables: make([]version.Table, len(tables))}
+	for i, t := range tables {
+		schema.Tables[i] = *t
+	}
+	return schema, nil
+}
+
+func closeRows(rows *sql.Rows) error {
+	err := rows.Err()
+	if closeErr := rows.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
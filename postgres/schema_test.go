@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestInspectSchema(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectQuery("FROM information_schema.tables").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("users"))
+	dbMock.ExpectQuery("FROM information_schema.columns").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "nullable", "default"}).
+			AddRow("users", "id", "integer", false, "").
+			AddRow("users", "name", "text", true, ""))
+	dbMock.ExpectQuery("FROM pg_index").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "index", "unique", "column"}).
+			AddRow("users", "users_pkey", true, "id").
+			AddRow("users", "users_name_id_idx", false, "name").
+			AddRow("users", "users_name_id_idx", false, "id"))
+	dbMock.ExpectQuery("FROM information_schema.table_constraints").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "name", "type", "column"}).
+			AddRow("users", "users_pkey", "PRIMARY KEY", "id"))
+
+	schema, err := (&PostgresSchemaInspector{}).InspectSchema(db)
+	assert.Nil(t, err)
+
+	expected := version.Schema{Tables: []version.Table{{
+		Name: "users",
+		Columns: []version.Column{
+			{Name: "id", Type: "integer"},
+			{Name: "name", Type: "text", Nullable: true},
+		},
+		Indexes: []version.Index{
+			{Name: "users_pkey", Columns: []string{"id"}, Unique: true},
+			{Name: "users_name_id_idx", Columns: []string{"name", "id"}},
+		},
+		Constraints: []version.Constraint{
+			{Name: "users_pkey", Type: "PRIMARY KEY", Columns: []string{"id"}},
+		},
+	}}}
+	assert.True(t, expected.Equal(schema), "Unexpected schema %+v", schema)
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestInspectSchemaDuplicatedConstraintName(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	// PostgreSQL only requires constraint names to be unique per table,
+	// the key columns must be joined on the table as well
+	dbMock.ExpectQuery("FROM information_schema.tables").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("orders").AddRow("users"))
+	dbMock.ExpectQuery("FROM information_schema.columns").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "nullable", "default"}).
+			AddRow("orders", "owner_id", "integer", false, "").
+			AddRow("users", "account_id", "integer", false, ""))
+	dbMock.ExpectQuery("FROM pg_index").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "index", "unique", "column"}))
+	dbMock.ExpectQuery("FROM information_schema.table_constraints .* AND kcu.table_name = tc.table_name").WithArgs("public").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "name", "type", "column"}).
+			AddRow("orders", "fk_owner", "FOREIGN KEY", "owner_id").
+			AddRow("users", "fk_owner", "FOREIGN KEY", "account_id"))
+
+	schema, err := (&PostgresSchemaInspector{}).InspectSchema(db)
+	assert.Nil(t, err)
+
+	expected := version.Schema{Tables: []version.Table{{
+		Name:        "orders",
+		Columns:     []version.Column{{Name: "owner_id", Type: "integer"}},
+		Constraints: []version.Constraint{{Name: "fk_owner", Type: "FOREIGN KEY", Columns: []string{"owner_id"}}},
+	}, {
+		Name:        "users",
+		Columns:     []version.Column{{Name: "account_id", Type: "integer"}},
+		Constraints: []version.Constraint{{Name: "fk_owner", Type: "FOREIGN KEY", Columns: []string{"account_id"}}},
+	}}}
+	assert.True(t, expected.Equal(schema), "Unexpected schema %+v", schema)
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestInspectSchemaQueryError(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectQuery("FROM information_schema.tables").WithArgs("app").
+		WillReturnError(sqlmock.ErrCancelled)
+
+	_, err = (&PostgresSchemaInspector{SchemaName: "app"}).InspectSchema(db)
+	assert.NotNil(t, err, "Query errors must be passed out")
+}
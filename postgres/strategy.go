@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+const defaultVersionTable = "schema_version"
+
+// NewPostgresStrategy returns a strategy storing the version in the
+// single row of tableName, schema_version when empty. The table is
+// created by the first call and its row is initialized to version
+// zero.
+//
+// The strategy implements version.TxStrategy. VersionTx reads the row
+// with SELECT ... FOR UPDATE, the row lock being held until the
+// transaction ends. PersistScheme reads the version again with it once
+// the migration transaction is opened, as PersistSchemeTx does, so
+// concurrent migrations are serialized on the row and the later one
+// resumes from the version the earlier one committed. Version, which
+// runs outside of any transaction, reads the row without locking it
+func NewPostgresStrategy(tableName string) version.Strategy {
+	if tableName == "" {
+		tableName = defaultVersionTable
+	}
+	return &postgresStrategy{table: tableName}
+}
+
+type postgresStrategy struct {
+	table string
+}
+
+// conn is implemented by *sql.DB and *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *postgresStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *postgresStrategy) SetVersion(db *sql.DB, v int) error {
+	return s.SetVersionContext(context.Background(), db, v)
+}
+
+func (s *postgresStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	return s.version(ctx, db, "")
+}
+
+func (s *postgresStrategy) SetVersionContext(ctx context.Context, db *sql.DB, v int) error {
+	return s.setVersion(ctx, db, v)
+}
+
+// VersionTx is like Version but reads within tx, locking the row until
+// tx ends
+func (s *postgresStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.version(context.Background(), tx, " FOR UPDATE")
+}
+
+// SetVersionTx is like SetVersion but writes within tx
+func (s *postgresStrategy) SetVersionTx(tx *sql.Tx, v int) error {
+	return s.setVersion(context.Background(), tx, v)
+}
+
+func (s *postgresStrategy) version(ctx context.Context, c conn, lock string) (int, error) {
+	if err := s.init(ctx, c); err != nil {
+		return 0, err
+	}
+
+	var v int
+	err := c.QueryRowContext(ctx, "SELECT version FROM "+s.table+lock).Scan(&v)
+	return v, err
+}
+
+func (s *postgresStrategy) setVersion(ctx context.Context, c conn, v int) error {
+	if err := s.init(ctx, c); err != nil {
+		return err
+	}
+
+	_, err := c.ExecContext(ctx, "UPDATE "+s.table+" SET version = $1", v)
+	return err
+}
+
+// init creates the table and its single row, the boolean primary key
+// restricted to true keeps a second row from being inserted
+func (s *postgresStrategy) init(ctx context.Context, c conn) error {
+	_, err := c.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+
+		" (id boolean PRIMARY KEY DEFAULT true CHECK (id), version integer NOT NULL)")
+	if err != nil {
+		return err
+	}
+	_, err = c.ExecContext(ctx, "INSERT INTO "+s.table+"(version) VALUES(0) ON CONFLICT DO NOTHING")
+	return err
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func expectInit(dbMock sqlmock.Sqlmock, table string) {
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS " + table).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("INSERT INTO " + table + "\\(version\\) VALUES\\(0\\) ON CONFLICT DO NOTHING").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+var _ version.TxStrategy = (*postgresStrategy)(nil)
+
+func TestPostgresStrategyVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	expectInit(dbMock, "versions")
+	dbMock.ExpectQuery("SELECT version FROM versions$").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+
+	v, err := NewPostgresStrategy("versions").Version(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, v, "Initialized table must report version zero")
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPostgresStrategySetVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectExec("UPDATE schema_version SET version = \\$1").WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, NewPostgresStrategy("").SetVersion(db, 3))
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPostgresStrategyInitError(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("INSERT INTO schema_version").WillReturnError(sqlmock.ErrCancelled)
+
+	_, err = NewPostgresStrategy("").Version(db)
+	assert.Equal(t, sqlmock.ErrCancelled, err)
+}
+
+func TestPostgresStrategyLocksWithinTx(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectBegin()
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectQuery("SELECT version FROM schema_version FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectExec("UPDATE schema_version SET version = \\$1").WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	s := NewPostgresStrategy("").(version.TxStrategy)
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	v, err := s.VersionTx(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+	assert.Nil(t, s.SetVersionTx(tx, 3))
+	assert.Nil(t, tx.Commit())
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("The row must be locked within the transaction. Err %q", err)
+	}
+}
+
+func TestPersistSchemeLocksVersion(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	r := version.NewRegistry()
+	r.Register("postgres", NewPostgresStrategy(""))
+	scheme := version.NewSQLScheme(2, "postgres", "", map[int]string{1: "ALTER TABLE users ADD email text"})
+
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectQuery("SELECT version FROM schema_version$").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectQuery("SELECT version FROM schema_version FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectExec("UPDATE schema_version SET version = \\$1").WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	res, err := r.PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, version.ActionUpdate, res.Action)
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("The version must be locked within the migration transaction. Err %q", err)
+	}
+}
+
+func TestPersistSchemeReplansConcurrentMigration(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	r := version.NewRegistry()
+	r.Register("postgres", NewPostgresStrategy(""))
+	scheme := version.NewSQLScheme(2, "postgres", "", map[int]string{1: "ALTER TABLE users ADD email text"})
+
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectQuery("SELECT version FROM schema_version$").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	expectInit(dbMock, "schema_version")
+	dbMock.ExpectQuery("SELECT version FROM schema_version FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectCommit()
+
+	res, err := r.PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, version.MigrationResult{Action: version.ActionNoop, FromVersion: 2, ToVersion: 2}, res,
+		"Updates applied meanwhile must not run again")
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
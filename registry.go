@@ -0,0 +1,147 @@
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds strategies by name. The package level functions use
+// DefaultRegistry, separate registries allow tests to register
+// strategies without affecting each other
+type Registry struct {
+	// NameNormalizer is applied to the names strategies are registered
+	// and looked up with, so "Postgres" and "postgres" name the same
+	// strategy. It defaults to strings.ToLower when nil and must be set
+	// before any strategy is registered
+	NameNormalizer func(string) string
+
+	mu           sync.RWMutex
+	strategies   map[string]Strategy
+	strategies64 map[string]Strategy64
+}
+
+// DefaultRegistry is the registry used by Register, PersistScheme and
+// the other package level functions
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{strategies: make(map[string]Strategy)}
+}
+
+// Register is like the package level Register but registers strategy
+// in r
+func (r *Registry) Register(name string, strategy Strategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if strategy == nil {
+		panic("versioned db: Register strategy is nil")
+	}
+	if r.registered(name) {
+		panic("versioned db: Register called twice for strategy " + name)
+	}
+	r.add(name, strategy)
+}
+
+// RegisterOrError is like the package level RegisterOrError but
+// registers strategy in r
+func (r *Registry) RegisterOrError(name string, strategy Strategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if strategy == nil {
+		return ErrNilStrategy
+	}
+	if r.registered(name) {
+		return ErrVersionAlreadyRegistered
+	}
+	r.add(name, strategy)
+	return nil
+}
+
+// add registers strategy, r.mu must be held
+func (r *Registry) add(name string, strategy Strategy) {
+	r.strategies[r.normalize(name)] = strategy
+}
+
+// registered tells whether a Strategy or a Strategy64 is registered
+// with name, r.mu must be held
+func (r *Registry) registered(name string) bool {
+	_, ok := r.strategies[r.normalize(name)]
+	_, ok64 := r.strategies64[r.normalize(name)]
+	return ok || ok64
+}
+
+// Unregister is like the package level Unregister but removes the
+// strategy from r
+func (r *Registry) Unregister(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.registered(name) {
+		return ErrStrategyNotFound
+	}
+	delete(r.strategies, r.normalize(name))
+	delete(r.strategies64, r.normalize(name))
+	return nil
+}
+
+// Strategies returns the sorted names of the strategies registered in
+// r, as normalized by NameNormalizer
+func (r *Registry) Strategies() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.names()
+}
+
+// names returns the sorted names of the strategies, those registered
+// by Register64 included, r.mu must be held
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.strategies)+len(r.strategies64))
+	for name := range r.strategies {
+		names = append(names, name)
+	}
+	for name := range r.strategies64 {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) lookup(name string) Strategy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.strategies[r.normalize(name)]
+}
+
+func (r *Registry) normalize(name string) string {
+	if r.NameNormalizer == nil {
+		return strings.ToLower(name)
+	}
+	return r.NameNormalizer(name)
+}
+
+// ValidateRegistry checks the strategies of DefaultRegistry, see
+// Registry.Validate
+func ValidateRegistry() []error {
+	return DefaultRegistry.Validate()
+}
+
+// Validate returns an error for every strategy of r that cannot be
+// looked up anymore, as NameNormalizer changed after it was registered
+func (r *Registry) Validate() []error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+	for _, name := range r.names() {
+		if normalized := r.normalize(name); normalized != name {
+			errs = append(errs, fmt.Errorf("%w: %q is registered but looked up as %q", ErrStrategyNotFound, name, normalized))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,132 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRegistryIsolated(t *testing.T) {
+	t.Parallel()
+
+	isolatedDB, isolatedMock, _ := sqlmock.New()
+	defer isolatedDB.Close()
+
+	isolated := new(versionStrategyMock)
+	isolated.
+		On("Version", isolatedDB).Return(0, nil).
+		On("SetVersion", isolatedDB, 1).Return(nil)
+
+	s := FuncScheme{
+		VersionFn:         func() int { return 1 },
+		VersionStrategyFn: func() string { return "isolated" },
+		OnCreateFn:        func(*sql.DB) error { return nil },
+	}
+
+	r := NewRegistry()
+	r.Register("isolated", isolated)
+	assert.Equal(t, []string{"isolated"}, r.Strategies())
+
+	_, err := PersistScheme(isolatedDB, s)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Strategy must not leak into DefaultRegistry")
+
+	isolatedMock.ExpectBegin()
+	isolatedMock.ExpectCommit()
+
+	_, err = r.PersistScheme(isolatedDB, s)
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	assert.Nil(t, r.Unregister("isolated"))
+	assert.Equal(t, ErrStrategyNotFound, r.Unregister("isolated"))
+
+	isolated.AssertExpectations(t)
+	err = isolatedMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestRegistryNormalizesNames(t *testing.T) {
+	r := NewRegistry()
+	s := new(versionStrategyMock)
+	r.Register("Postgres", s)
+
+	assert.Equal(t, s, r.lookup("postgres"), "Names must be case insensitive by default")
+	assert.Equal(t, ErrVersionAlreadyRegistered, r.RegisterOrError("POSTGRES", s))
+	assert.Equal(t, []string{"postgres"}, r.Strategies())
+	assert.Empty(t, r.Validate())
+}
+
+func TestRegistryValidate(t *testing.T) {
+	r := &Registry{NameNormalizer: func(name string) string { return name }, strategies: make(map[string]Strategy)}
+	r.Register("Postgres", new(versionStrategyMock))
+	r.Register("mysql", new(versionStrategyMock))
+
+	r.NameNormalizer = nil
+	errs := r.Validate()
+	if assert.Len(t, errs, 1, "Unreachable strategies must be reported") {
+		assert.True(t, errors.Is(errs[0], ErrStrategyNotFound))
+		assert.Contains(t, errs[0].Error(), `"Postgres"`)
+	}
+}
+
+func TestRegistryStrategies64(t *testing.T) {
+	r := &Registry{NameNormalizer: func(name string) string { return name }, strategies: make(map[string]Strategy)}
+	r.Register("table", new(versionStrategyMock))
+	r.Register64("Timestamps", new(strategy64Mock))
+
+	assert.Equal(t, []string{"Timestamps", "table"}, r.Strategies(), "Strategy64 names must be listed")
+	assert.Equal(t, ErrVersionAlreadyRegistered, r.RegisterOrError("Timestamps", new(versionStrategyMock)),
+		"Names must not be shared with a Strategy64")
+	assert.Panics(t, func() { r.Register64("table", new(strategy64Mock)) }, "Names must not be shared with a Strategy")
+
+	r.NameNormalizer = strings.ToLower
+	errs := r.Validate()
+	if assert.Len(t, errs, 1, "Unreachable Strategy64 must be reported") {
+		assert.Contains(t, errs[0].Error(), `"Timestamps"`)
+	}
+
+	r.NameNormalizer = func(name string) string { return name }
+	assert.Nil(t, r.Unregister("Timestamps"), "Strategy64 must be unregistered")
+	assert.Nil(t, r.lookup64("Timestamps"))
+	assert.Equal(t, []string{"table"}, r.Strategies())
+}
+
+func TestRegistryHistoryWarmUpAndReserver(t *testing.T) {
+	t.Parallel()
+
+	isolatedDB, isolatedMock, _ := sqlmock.New()
+	defer isolatedDB.Close()
+
+	isolated := new(versionStrategyMock)
+	isolated.On("Version", isolatedDB).Return(2, nil)
+	r := NewRegistry()
+	r.Register("isolated", isolated)
+
+	_, err := GetMigrationHistory(isolatedDB, "isolated")
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Strategy must not leak into DefaultRegistry")
+	_, err = r.GetMigrationHistory(isolatedDB, "isolated")
+	assert.Equal(t, ErrHistoryNotSupported, err, "GetMigrationHistory must look the strategy up in r")
+
+	assert.True(t, errors.Is(WarmUp(isolatedDB, "isolated"), ErrUnknownStrategy))
+	assert.Nil(t, r.WarmUp(isolatedDB, "isolated"), "WarmUp must look the strategy up in r")
+
+	isolatedMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version_reservations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	isolatedMock.ExpectQuery("INSERT INTO schema_version_reservations").WithArgs("isolated", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+
+	v, err := r.NewVersionReserver("isolated").ReserveVersion(context.Background(), isolatedDB)
+	assert.Nil(t, err, "The reserver must look the strategy up in r")
+	assert.Equal(t, 3, v)
+
+	err = isolatedMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
@@ -0,0 +1,89 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const reservationsTable = "schema_version_reservations"
+
+// VersionReserver hands out scheme version numbers for a strategy so
+// that migrations written in parallel never claim the same version.
+// Reservations are stored in the schema_version_reservations table,
+// whose primary key guarantees that a version is reserved only once.
+//
+// The queries use INSERT ... RETURNING and $n placeholders, as
+// supported by PostgreSQL.
+type VersionReserver struct {
+	registry     *Registry
+	strategyName string
+}
+
+// NewVersionReserver returns a VersionReserver for the strategy
+// registered by strategyName
+func NewVersionReserver(strategyName string) *VersionReserver {
+	return DefaultRegistry.NewVersionReserver(strategyName)
+}
+
+// NewVersionReserver is like the package level NewVersionReserver but
+// the reserver looks strategyName up in r
+func (r *Registry) NewVersionReserver(strategyName string) *VersionReserver {
+	return &VersionReserver{registry: r, strategyName: strategyName}
+}
+
+// ReserveVersion reserves the version following both the version
+// recorded by the strategy and the highest version already reserved
+func (r *VersionReserver) ReserveVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var strategy Strategy
+
+	if db == nil {
+		return 0, ErrNilDB
+	}
+
+	if strategy = r.registry.lookup(r.strategyName); strategy == nil {
+		return 0, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, r.strategyName)
+	}
+
+	current, err := strategyVersion(ctx, strategy, db)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = r.createTable(ctx, db); err != nil {
+		return 0, err
+	}
+
+	var reserved int
+	err = db.QueryRowContext(ctx, "INSERT INTO "+reservationsTable+" (strategy, version) "+
+		"SELECT $1, GREATEST(COALESCE(MAX(version), 0), $2) + 1 FROM "+reservationsTable+" WHERE strategy = $1 "+
+		"RETURNING version", r.strategyName, current).Scan(&reserved)
+	if err != nil {
+		return 0, err
+	}
+	return reserved, nil
+}
+
+// ReleaseVersion gives a reserved version back, so it may be reserved
+// again if no higher version was reserved meanwhile
+func (r *VersionReserver) ReleaseVersion(ctx context.Context, db *sql.DB, version int) error {
+	if db == nil {
+		return ErrNilDB
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM "+reservationsTable+" WHERE strategy = $1 AND version = $2",
+		r.strategyName, version)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("versioned db: version %d is not reserved for %q", version, r.strategyName)
+	}
+	return nil
+}
+
+func (r *VersionReserver) createTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+reservationsTable+
+		" (strategy text NOT NULL, version integer NOT NULL, PRIMARY KEY (strategy, version))")
+	return err
+}
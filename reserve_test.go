@@ -0,0 +1,64 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestReserveVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(3, nil)
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_version_reservations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("INSERT INTO schema_version_reservations").
+		WithArgs("fake", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+
+	reserved, err := NewVersionReserver("fake").ReserveVersion(context.Background(), db)
+	assert.Nil(t, err, "ReserveVersion must not return error")
+	assert.Equal(t, 4, reserved, "Reserved version not returned")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestReserveVersionUnknownStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, err := NewVersionReserver("not_registered").ReserveVersion(context.Background(), db)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "An error must be returned when a strategy is not registered")
+}
+
+func TestReleaseVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("DELETE FROM schema_version_reservations").
+		WithArgs("fake", 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := NewVersionReserver("fake").ReleaseVersion(context.Background(), db, 4)
+	assert.Nil(t, err, "ReleaseVersion must not return error")
+}
+
+func TestReleaseVersionNotReserved(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("DELETE FROM schema_version_reservations").
+		WithArgs("fake", 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := NewVersionReserver("fake").ReleaseVersion(context.Background(), db, 9)
+	assert.NotNil(t, err, "Releasing a version that is not reserved must return error")
+}
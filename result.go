@@ -0,0 +1,52 @@
+package version
+
+// MigrationAction tells which migration PersistScheme ran
+type MigrationAction int
+
+const (
+	// ActionNoop means the database was left untouched. It is the zero
+	// value, so a zero MigrationResult reports a noop
+	ActionNoop MigrationAction = iota
+	// ActionCreate means OnCreate ran
+	ActionCreate
+	// ActionUpdate means OnUpdate ran once for every version step
+	ActionUpdate
+	// ActionDowngrade means OnDowngrade ran
+	ActionDowngrade
+)
+
+// String returns "noop", "create", "update" or "downgrade"
+func (a MigrationAction) String() string {
+	switch a {
+	case ActionCreate:
+		return "create"
+	case ActionUpdate:
+		return "update"
+	case ActionDowngrade:
+		return "downgrade"
+	}
+	return "noop"
+}
+
+// MigrationResult describes the migration PersistScheme ran. For a
+// noop both versions are the version the database was found at.
+// Description is only set for schemes implementing SchemeDescriber
+type MigrationResult struct {
+	Action      MigrationAction
+	FromVersion int
+	ToVersion   int
+	Description string
+}
+
+// SchemeDescriber is implemented by schemes carrying a human readable
+// description, reported in the logs and in MigrationResult
+type SchemeDescriber interface {
+	Description() string
+}
+
+func schemeDescription(scheme Scheme) string {
+	if s, ok := scheme.(SchemeDescriber); ok {
+		return s.Description()
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+)
+
+// RetryableError is implemented by strategies telling which of their
+// errors are transient and worth retrying under WithRetry. Every error
+// is retried for strategies not implementing it
+type RetryableError interface {
+	IsRetryable(err error) bool
+}
+
+type retryConfig struct {
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// WithRetry makes PersistScheme read the database version up to
+// maxAttempts times when the strategy fails. The delay between
+// attempts starts at baseDelay and doubles after each attempt, with a
+// random jitter of up to half of it. All attempts share the deadline of
+// the migration context
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(o *options) {
+		o.retry = &retryConfig{maxAttempts: maxAttempts, baseDelay: baseDelay}
+	}
+}
+
+// readVersion reads the version of db, retrying as set by WithRetry
+func (o *options) readVersion(ctx context.Context, strategy Strategy, db *sql.DB) (int, error) {
+	version, err := strategyVersion(ctx, strategy, db)
+	if o.retry == nil {
+		return version, err
+	}
+
+	delay := o.retry.baseDelay
+	for attempt := 1; err != nil && attempt < o.retry.maxAttempts; attempt++ {
+		if s, ok := strategy.(RetryableError); ok && !s.IsRetryable(err) {
+			break
+		}
+		logf("reading the database version failed, attempt %d of %d: %v", attempt, o.retry.maxAttempts, err)
+
+		wait := delay
+		if delay > 1 {
+			wait += time.Duration(rand.Int63n(int64(delay / 2)))
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+		delay *= 2
+
+		version, err = strategyVersion(ctx, strategy, db)
+	}
+	return version, err
+}
@@ -0,0 +1,84 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetry(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, someError).Twice().
+		On("Version", db).Return(1, nil).Once()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme, WithRetry(3, time.Millisecond))
+	assert.Nil(t, err, "Version read must be retried")
+	strategy.AssertExpectations(t)
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(0, someError).Times(2)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme, WithRetry(2, time.Millisecond))
+	assert.Equal(t, someError, err, "Last error must be returned")
+	strategy.AssertExpectations(t)
+}
+
+func TestWithRetryNotRetryable(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	calls := 0
+	Register("permanent", &retryableStrategyMock{StrategyFunc{
+		VersionFn: func(*sql.DB) (int, error) { calls++; return 0, someError },
+	}})
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("permanent")
+
+	_, err := PersistScheme(db, scheme, WithRetry(5, time.Millisecond))
+	assert.Equal(t, someError, err)
+	assert.Equal(t, 1, calls, "Errors reported as permanent must not be retried")
+}
+
+func TestWithRetryDeadline(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(0, someError)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := PersistSchemeContext(ctx, db, scheme, WithRetry(100, 5*time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, err, "Attempts must share the context deadline")
+}
+
+// Stubs
+/////////////////////////////////////////////////////
+
+type retryableStrategyMock struct {
+	StrategyFunc
+}
+
+func (s *retryableStrategyMock) IsRetryable(err error) bool {
+	return false
+}
@@ -0,0 +1,82 @@
+package version
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaInspector reads the structure of a database so that two
+// states of the same database can be compared
+type SchemaInspector interface {
+	InspectSchema(db *sql.DB) (Schema, error)
+}
+
+// Schema is a snapshot of the tables of a database
+type Schema struct {
+	Tables []Table
+}
+
+// Table describes a single table of a Schema
+type Table struct {
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	Constraints []Constraint
+}
+
+// Column describes a table column
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
+}
+
+// Index describes a table index by the columns it covers
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// Constraint describes a table constraint such as a primary key,
+// a foreign key, a unique or a check constraint
+type Constraint struct {
+	Name    string
+	Type    string
+	Columns []string
+}
+
+// Equal reports whether both schemas describe the same database
+// structure. Tables, indexes and constraints are compared regardless
+// of the order in which they were inspected, columns are compared in
+// the order they were declared.
+func (s Schema) Equal(other Schema) bool {
+	return reflect.DeepEqual(s.normalized(), other.normalized())
+}
+
+func (s Schema) normalized() Schema {
+	tables := make([]Table, len(s.Tables))
+	for i, t := range s.Tables {
+		tables[i] = Table{
+			Name:        t.Name,
+			Columns:     append([]Column{}, t.Columns...),
+			Indexes:     append([]Index{}, t.Indexes...),
+			Constraints: append([]Constraint{}, t.Constraints...),
+		}
+		sort.Slice(tables[i].Indexes, func(a, b int) bool {
+			return tables[i].Indexes[a].Name < tables[i].Indexes[b].Name
+		})
+		sort.Slice(tables[i].Constraints, func(a, b int) bool {
+			return constraintKey(tables[i].Constraints[a]) < constraintKey(tables[i].Constraints[b])
+		})
+	}
+	sort.Slice(tables, func(a, b int) bool { return tables[a].Name < tables[b].Name })
+	return Schema{Tables: tables}
+}
+
+func constraintKey(c Constraint) string {
+	return c.Type + "\x00" + c.Name + "\x00" + strings.Join(c.Columns, "\x00")
+}
@@ -0,0 +1,65 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleSchema() Schema {
+	return Schema{Tables: []Table{
+		{
+			Name: "users",
+			Columns: []Column{
+				{Name: "id", Type: "integer"},
+				{Name: "name", Type: "text", Nullable: true},
+			},
+			Indexes: []Index{
+				{Name: "users_name_idx", Columns: []string{"name"}},
+				{Name: "users_pkey", Columns: []string{"id"}, Unique: true},
+			},
+			Constraints: []Constraint{
+				{Name: "users_pkey", Type: "PRIMARY KEY", Columns: []string{"id"}},
+			},
+		},
+		{
+			Name:    "roles",
+			Columns: []Column{{Name: "id", Type: "integer"}},
+		},
+	}}
+}
+
+func TestSchemaEqual(t *testing.T) {
+	assert.True(t, sampleSchema().Equal(sampleSchema()), "Identical schemas must be equal")
+}
+
+func TestSchemaEqualIgnoresTableAndIndexOrder(t *testing.T) {
+	other := sampleSchema()
+	other.Tables[0], other.Tables[1] = other.Tables[1], other.Tables[0]
+	indexes := other.Tables[1].Indexes
+	indexes[0], indexes[1] = indexes[1], indexes[0]
+
+	assert.True(t, sampleSchema().Equal(other), "Table and index order must not matter")
+}
+
+func TestSchemaNotEqualOnColumnChange(t *testing.T) {
+	other := sampleSchema()
+	other.Tables[0].Columns[1].Nullable = false
+
+	assert.False(t, sampleSchema().Equal(other), "Column changes must be detected")
+}
+
+func TestSchemaNotEqualOnColumnOrder(t *testing.T) {
+	other := sampleSchema()
+	columns := other.Tables[0].Columns
+	columns[0], columns[1] = columns[1], columns[0]
+
+	assert.False(t, sampleSchema().Equal(other), "Column order must be taken into account")
+}
+
+func TestSchemaNotEqualOnMissingTable(t *testing.T) {
+	other := sampleSchema()
+	other.Tables = other.Tables[:1]
+
+	assert.False(t, sampleSchema().Equal(other), "Missing tables must be detected")
+}
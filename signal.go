@@ -0,0 +1,109 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// critical tracks the steps run by IgnoreSignalsDuring, delaying the
+// cancellations triggered by signals until they complete
+var critical struct {
+	sync.Mutex
+	depth    int
+	handlers int
+	delayed  []func()
+}
+
+// RunWithSignalHandler persists scheme like PersistSchemeContext, but
+// stops the migration when one of signals is received, SIGINT and
+// SIGTERM by default. The statement being executed is allowed to
+// finish and the migration is rolled back at the next step boundary
+func RunWithSignalHandler(ctx context.Context, db *sql.DB, scheme Scheme, signals ...os.Signal) (MigrationResult, error) {
+	if len(signals) == 0 {
+		signals = defaultSignals
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	critical.Lock()
+	critical.handlers++
+	critical.Unlock()
+	defer func() {
+		signal.Stop(ch)
+		critical.Lock()
+		critical.handlers--
+		critical.Unlock()
+	}()
+
+	go func() {
+		select {
+		case <-ch:
+			afterCriticalSteps(cancel)
+		case <-ctx.Done():
+		}
+	}()
+
+	return PersistSchemeContext(ctx, db, scheme, func(o *options) { o.detached = true })
+}
+
+// IgnoreSignalsDuring runs fn, a step that must not be interrupted.
+// SIGINT and SIGTERM received meanwhile only take effect once fn has
+// returned: RunWithSignalHandler migrations are stopped afterwards,
+// and the signal is raised again when no migration is handling it.
+// It returns the error returned by fn
+func IgnoreSignalsDuring(fn func() error) error {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, defaultSignals...)
+
+	critical.Lock()
+	critical.depth++
+	critical.Unlock()
+
+	err := fn()
+
+	signal.Stop(ch)
+	critical.Lock()
+	critical.depth--
+	var delayed []func()
+	if critical.depth == 0 {
+		delayed, critical.delayed = critical.delayed, nil
+	}
+	handled := critical.handlers > 0
+	critical.Unlock()
+
+	for _, f := range delayed {
+		f()
+	}
+
+	select {
+	case sig := <-ch:
+		if !handled {
+			if p, findErr := os.FindProcess(os.Getpid()); findErr == nil {
+				p.Signal(sig)
+			}
+		}
+	default:
+	}
+	return err
+}
+
+// afterCriticalSteps calls f once no IgnoreSignalsDuring step runs
+func afterCriticalSteps(f func()) {
+	critical.Lock()
+	if critical.depth > 0 {
+		critical.delayed = append(critical.delayed, f)
+		critical.Unlock()
+		return
+	}
+	critical.Unlock()
+	f()
+}
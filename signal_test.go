@@ -0,0 +1,92 @@
+//go:build !windows
+
+package version
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func raise(t *testing.T, sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	assert.Nil(t, err)
+	assert.Nil(t, p.Signal(sig))
+}
+
+func TestRunWithSignalHandlerStopsAtStepBoundary(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(0, nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil).
+		Run(func(mock.Arguments) {
+			raise(t, syscall.SIGUSR1)
+			time.Sleep(100 * time.Millisecond)
+		})
+	dbMock.ExpectRollback()
+
+	_, err := RunWithSignalHandler(context.Background(), db, scheme, syscall.SIGUSR1)
+	assert.Equal(t, context.Canceled, err, "A signal must stop the migration")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestRunWithSignalHandlerWithoutSignal(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := RunWithSignalHandler(context.Background(), db, scheme, syscall.SIGUSR1)
+	assert.Nil(t, err, "RunWithSignalHandler must not return error on create")
+}
+
+func TestIgnoreSignalsDuringDelaysSignal(t *testing.T) {
+	received := make(chan os.Signal, 2)
+	signal.Notify(received, syscall.SIGTERM)
+	defer signal.Stop(received)
+
+	var ranAfterSignal bool
+	err := IgnoreSignalsDuring(func() error {
+		raise(t, syscall.SIGTERM)
+		time.Sleep(50 * time.Millisecond)
+		ranAfterSignal = true
+		return someError
+	})
+	assert.Equal(t, someError, err, "fn error must be passed out")
+	assert.True(t, ranAfterSignal, "fn must run to completion")
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("Signal must be raised again once fn returned")
+		}
+	}
+}
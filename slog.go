@@ -0,0 +1,38 @@
+package version
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithSlogLogger makes PersistScheme write a structured record to
+// logger for every MigrationEvent, with the strategy, from_version,
+// to_version and action attributes. Finished migrations add the
+// duration attribute, and the error one when they failed, which is
+// then logged at the error level
+func WithSlogLogger(logger *slog.Logger) Option {
+	return WithEventListener(slogListener{logger})
+}
+
+type slogListener struct {
+	logger *slog.Logger
+}
+
+func (l slogListener) OnMigrationEvent(event MigrationEvent) {
+	attrs := []slog.Attr{
+		slog.String("strategy", event.StrategyName),
+		slog.Int("from_version", event.OldVersion),
+		slog.Int("to_version", event.NewVersion),
+		slog.String("action", event.Action.String()),
+	}
+	if event.EventType != MigrationStarted {
+		attrs = append(attrs, slog.Duration("duration", event.Duration))
+	}
+
+	level := slog.LevelInfo
+	if event.Error != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", event.Error.Error()))
+	}
+	l.logger.LogAttrs(context.Background(), level, "migration "+string(event.EventType), attrs...)
+}
@@ -0,0 +1,68 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSlogLogger(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme, WithSlogLogger(logger))
+	assert.Nil(t, err, "PersistScheme must not return error on update")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var record map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(lines[1]), &record))
+		assert.Equal(t, "migration succeeded", record["msg"])
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "fake", record["strategy"])
+		assert.Equal(t, float64(1), record["from_version"])
+		assert.Equal(t, float64(2), record["to_version"])
+		assert.Equal(t, "update", record["action"])
+		assert.Contains(t, record, "duration")
+	}
+}
+
+func TestPersistSchemeOptions(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var buf bytes.Buffer
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	err := PersistSchemeOptions(db, scheme, WithSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	assert.Nil(t, err, "PersistSchemeOptions must not return error")
+	assert.Contains(t, buf.String(), "migration succeeded", "Options must be applied")
+}
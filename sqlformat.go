@@ -0,0 +1,282 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiKeyword = "\x1b[1;34m"
+	ansiString  = "\x1b[32m"
+	ansiComment = "\x1b[90m"
+)
+
+var sqlKeywords = map[string]bool{
+	"ADD": true, "ALTER": true, "AND": true, "AS": true, "ASC": true, "BEGIN": true,
+	"BY": true, "CASCADE": true, "CHECK": true, "COLUMN": true, "COMMIT": true,
+	"CONSTRAINT": true, "CREATE": true, "DEFAULT": true, "DELETE": true, "DESC": true,
+	"DROP": true, "EXISTS": true, "FOREIGN": true, "FROM": true, "GROUP": true,
+	"IF": true, "IN": true, "INDEX": true, "INSERT": true, "INTO": true, "IS": true,
+	"JOIN": true, "KEY": true, "LIKE": true, "LIMIT": true, "NOT": true, "NULL": true,
+	"ON": true, "OR": true, "ORDER": true, "PRIMARY": true, "REFERENCES": true,
+	"RENAME": true, "ROLLBACK": true, "SELECT": true, "SET": true, "TABLE": true,
+	"TO": true, "UNIQUE": true, "UPDATE": true, "USING": true, "VALUES": true,
+	"VIEW": true, "WHERE": true, "WITH": true,
+}
+
+// FormatSQL renders stmts for human review. Scripts holding several
+// statements are split, each statement is numbered, annotated with its
+// line count and indented. Keywords, strings and comments are
+// highlighted with ANSI colors when the standard output is a terminal
+func FormatSQL(stmts []string) string {
+	return formatSQL(stmts, isTerminal(os.Stdout))
+}
+
+func formatSQL(stmts []string, color bool) string {
+	var (
+		b       strings.Builder
+		n       int
+		pending string
+	)
+
+	for _, script := range stmts {
+		for _, stmt := range splitSQL(script) {
+			if !hasCode(stmt) {
+				pending += stmt + "\n"
+				continue
+			}
+			stmt = strings.TrimSpace(pending + stmt)
+			pending = ""
+			n++
+
+			lines := strings.Count(stmt, "\n") + 1
+			suffix := "s"
+			if lines == 1 {
+				suffix = ""
+			}
+			header := fmt.Sprintf("-- Statement %d (%d line%s)", n, lines, suffix)
+			if color {
+				header = ansiComment + header + ansiReset
+			}
+			b.WriteString(header + "\n")
+			// The terminator goes before trailing comments, a line
+			// comment would swallow it
+			code, comments := trailingComments(stmt)
+			writeIndented(&b, highlightSQL(code, color)+";"+highlightSQL(comments, color))
+			b.WriteString("\n")
+		}
+	}
+	if pending = strings.TrimSpace(pending); pending != "" {
+		writeIndented(&b, highlightSQL(pending, color))
+	}
+	return b.String()
+}
+
+// trailingComments splits stmt in its code and the comments following
+// it, along with the blanks separating them
+func trailingComments(stmt string) (code, comments string) {
+	end, pos := 0, 0
+	for _, t := range scanSQL(stmt) {
+		pos += len(t.text)
+		if t.kind != 'c' && strings.TrimSpace(t.text) != "" {
+			end = pos
+		}
+	}
+	code = strings.TrimRight(stmt[:end], " \t\r\n")
+	return code, stmt[len(code):]
+}
+
+func writeIndented(b *strings.Builder, s string) {
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString("    " + line + "\n")
+	}
+}
+
+// sqlToken is a piece of a SQL script as seen by scanSQL
+type sqlToken struct {
+	kind byte // 'c' comment, 's' quoted string, ';' separator, 0 anything else
+	text string
+}
+
+// scanSQL splits script in comments, quoted strings, statement
+// separators and plain text
+func scanSQL(script string) []sqlToken {
+	var tokens []sqlToken
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			tokens = append(tokens, sqlToken{text: script[start:end]})
+		}
+	}
+
+	for i := 0; i < len(script); {
+		var kind byte
+		end := i
+		switch {
+		case strings.HasPrefix(script[i:], "--"):
+			kind = 'c'
+			if end = strings.IndexByte(script[i:], '\n'); end < 0 {
+				end = len(script)
+			} else {
+				end += i
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			kind = 'c'
+			if end = strings.Index(script[i+2:], "*/"); end < 0 {
+				end = len(script)
+			} else {
+				end += i + 4
+			}
+		case script[i] == '\'' || script[i] == '"':
+			kind = 's'
+			end = i + 1
+			for end < len(script) {
+				if script[end] == script[i] {
+					if end+1 < len(script) && script[end+1] == script[i] {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+		case script[i] == '$' && (i == 0 || !isIdentByte(script[i-1])) && dollarTag(script[i:]) != "":
+			// PostgreSQL dollar quoted strings, such as function
+			// bodies, run up to the same $tag$
+			kind = 's'
+			tag := dollarTag(script[i:])
+			if end = strings.Index(script[i+len(tag):], tag); end < 0 {
+				end = len(script)
+			} else {
+				end += i + 2*len(tag)
+			}
+		case script[i] == ';':
+			kind = ';'
+			end = i + 1
+		default:
+			i++
+			continue
+		}
+		flush(i)
+		tokens = append(tokens, sqlToken{kind: kind, text: script[i:end]})
+		i, start = end, end
+	}
+	flush(len(script))
+	return tokens
+}
+
+// dollarTag returns the $tag$ opening the dollar quoted string s
+// starts with, empty if it starts with none. Tags do not start with a
+// digit, which keeps $1 placeholders out
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '$':
+			return s[:j+1]
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || j > 1 && '0' <= c && c <= '9':
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// splitSQL splits script on the semicolons that are not part of a
+// comment, a quoted string or a dollar quoted string
+func splitSQL(script string) []string {
+	var (
+		stmts []string
+		cur   strings.Builder
+	)
+	for _, t := range scanSQL(script) {
+		if t.kind == ';' {
+			stmts = append(stmts, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteString(t.text)
+	}
+	if strings.TrimSpace(cur.String()) != "" {
+		stmts = append(stmts, cur.String())
+	}
+
+	nonEmpty := stmts[:0]
+	for _, s := range stmts {
+		if s = strings.TrimSpace(s); s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return nonEmpty
+}
+
+// hasCode reports whether stmt holds anything but comments
+func hasCode(stmt string) bool {
+	for _, t := range scanSQL(stmt) {
+		if t.kind != 'c' && strings.TrimSpace(t.text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func highlightSQL(stmt string, color bool) string {
+	if !color {
+		return stmt
+	}
+
+	var b strings.Builder
+	for _, t := range scanSQL(stmt) {
+		switch t.kind {
+		case 'c':
+			b.WriteString(ansiComment + t.text + ansiReset)
+		case 's':
+			b.WriteString(ansiString + t.text + ansiReset)
+		default:
+			b.WriteString(highlightKeywords(t.text))
+		}
+	}
+	return b.String()
+}
+
+func highlightKeywords(text string) string {
+	var b strings.Builder
+	word := -1
+	for i := 0; i <= len(text); i++ {
+		isWord := i < len(text) && (text[i] == '_' ||
+			'a' <= text[i] && text[i] <= 'z' || 'A' <= text[i] && text[i] <= 'Z' ||
+			word >= 0 && '0' <= text[i] && text[i] <= '9')
+		if isWord {
+			if word < 0 {
+				word = i
+			}
+			continue
+		}
+		if word >= 0 {
+			if w := text[word:i]; sqlKeywords[strings.ToUpper(w)] {
+				b.WriteString(ansiKeyword + w + ansiReset)
+			} else {
+				b.WriteString(w)
+			}
+			word = -1
+		}
+		if i < len(text) {
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,65 @@
+package version
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSQLSplitsAndNumbersStatements(t *testing.T) {
+	out := formatSQL([]string{
+		"CREATE TABLE users (\n  id serial,\n  name text\n); CREATE INDEX users_name ON users (name)",
+		"INSERT INTO users (name) VALUES ('a;b')",
+	}, false)
+
+	expected := "-- Statement 1 (4 lines)\n" +
+		"    CREATE TABLE users (\n" +
+		"      id serial,\n" +
+		"      name text\n" +
+		"    );\n" +
+		"\n" +
+		"-- Statement 2 (1 line)\n" +
+		"    CREATE INDEX users_name ON users (name);\n" +
+		"\n" +
+		"-- Statement 3 (1 line)\n" +
+		"    INSERT INTO users (name) VALUES ('a;b');\n" +
+		"\n"
+	assert.Equal(t, expected, out)
+}
+
+func TestFormatSQLKeepsComments(t *testing.T) {
+	out := formatSQL([]string{
+		"-- users; the main table\nCREATE TABLE users (id int); /* done; really */",
+	}, false)
+
+	expected := "-- Statement 1 (2 lines)\n" +
+		"    -- users; the main table\n" +
+		"    CREATE TABLE users (id int);\n" +
+		"\n" +
+		"    /* done; really */\n"
+	assert.Equal(t, expected, out)
+}
+
+func TestFormatSQLHighlights(t *testing.T) {
+	out := formatSQL([]string{"select 'from' from t1 -- where"}, true)
+
+	assert.True(t, strings.Contains(out, ansiKeyword+"select"+ansiReset), "Keywords must be highlighted")
+	assert.True(t, strings.Contains(out, ansiString+"'from'"+ansiReset), "Strings must be highlighted")
+	assert.True(t, strings.Contains(out, "t1; "+ansiComment+"-- where"+ansiReset+"\n"),
+		"Comments must be highlighted, after the terminator")
+	assert.False(t, strings.Contains(out, ansiKeyword+"t1"), "Identifiers must not be highlighted")
+}
+
+func TestFormatSQLTerminatesBeforeTrailingComment(t *testing.T) {
+	out := formatSQL([]string{"SELECT 1 -- one\n-- and more\n; SELECT 2 /* two */"}, false)
+
+	expected := "-- Statement 1 (2 lines)\n" +
+		"    SELECT 1; -- one\n" +
+		"    -- and more\n" +
+		"\n" +
+		"-- Statement 2 (1 line)\n" +
+		"    SELECT 2; /* two */\n" +
+		"\n"
+	assert.Equal(t, expected, out)
+}
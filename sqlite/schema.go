@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gabriel-araujjo/versioned-database"
+)
+
+const tablesQuery = `SELECT name FROM sqlite_master
+WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+ORDER BY name`
+
+// SQLiteSchemaInspector inspects a SQLite database using
+// sqlite_master and the table PRAGMAs
+type SQLiteSchemaInspector struct{}
+
+// InspectSchema reads tables, columns, indexes and constraints
+// of the SQLite database
+func (SQLiteSchemaInspector) InspectSchema(db *sql.DB) (version.Schema, error) {
+	names, err := queryStrings(db, tablesQuery)
+	if err != nil {
+		return version.Schema{}, err
+	}
+
+	schema := version.Schema{Tables: make([]version.Table, 0, len(names))}
+	for _, name := range names {
+		t := version.Table{Name: name}
+		if err = inspectColumns(db, &t); err != nil {
+			return version.Schema{}, err
+		}
+		if err = inspectIndexes(db, &t); err != nil {
+			return version.Schema{}, err
+		}
+		if err = inspectForeignKeys(db, &t); err != nil {
+			return version.Schema{}, err
+		}
+		schema.Tables = append(schema.Tables, t)
+	}
+	return schema, nil
+}
+
+func inspectColumns(db *sql.DB, t *version.Table) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", quote(t.Name)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var primaryKey []string
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			c                version.Column
+			def              sql.NullString
+		)
+		if err = rows.Scan(&cid, &c.Name, &c.Type, &notNull, &def, &pk); err != nil {
+			return err
+		}
+		c.Nullable = notNull == 0
+		c.Default = def.String
+		t.Columns = append(t.Columns, c)
+		if pk > 0 {
+			for len(primaryKey) < pk {
+				primaryKey = append(primaryKey, "")
+			}
+			primaryKey[pk-1] = c.Name
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(primaryKey) > 0 {
+		t.Constraints = append(t.Constraints, version.Constraint{Type: "PRIMARY KEY", Columns: primaryKey})
+	}
+	return nil
+}
+
+func inspectIndexes(db *sql.DB, t *version.Table) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", quote(t.Name)))
+	if err != nil {
+		return err
+	}
+
+	var indexes []version.Index
+	for rows.Next() {
+		var (
+			seq, unique, partial int
+			name, origin         string
+		)
+		if err = rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return err
+		}
+		indexes = append(indexes, version.Index{Name: name, Unique: unique != 0})
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for i := range indexes {
+		columns, err := queryIndexColumns(db, indexes[i].Name)
+		if err != nil {
+			return err
+		}
+		indexes[i].Columns = columns
+	}
+	t.Indexes = indexes
+	return nil
+}
+
+func queryIndexColumns(db *sql.DB, index string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", quote(index)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			seqno, cid int
+			name       sql.NullString
+		)
+		if err = rows.Scan(&seqno, &cid, &name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name.String)
+	}
+	return columns, rows.Err()
+}
+
+func inspectForeignKeys(db *sql.DB, t *version.Table) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA foreign_key_list(%s)", quote(t.Name)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byID := make(map[int]int)
+	for rows.Next() {
+		var (
+			id, seq                                int
+			table, from, onUpdate, onDelete, match string
+			to                                     sql.NullString
+		)
+		if err = rows.Scan(&id, &seq, &table, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return err
+		}
+		i, ok := byID[id]
+		if !ok {
+			i = len(t.Constraints)
+			byID[id] = i
+			t.Constraints = append(t.Constraints, version.Constraint{
+				Name: fmt.Sprintf("%s_fk_%d", t.Name, id),
+				Type: "FOREIGN KEY",
+			})
+		}
+		t.Constraints[i].Columns = append(t.Constraints[i].Columns, from)
+	}
+	return rows.Err()
+}
+
+func queryStrings(db *sql.DB, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err = rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func quote(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gabriel-araujjo/versioned-database"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestInspectSchema(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	assert.Nil(t, err)
+	defer db.Close()
+
+	dbMock.ExpectQuery("FROM sqlite_master").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("posts"))
+	dbMock.ExpectQuery(regexp.QuoteMeta(`PRAGMA table_info("posts")`)).
+		WillReturnRows(sqlmock.NewRows([]string{"cid", "name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow(0, "id", "INTEGER", 1, nil, 1).
+			AddRow(1, "user_id", "INTEGER", 0, nil, 0))
+	dbMock.ExpectQuery(regexp.QuoteMeta(`PRAGMA index_list("posts")`)).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "name", "unique", "origin", "partial"}).
+			AddRow(0, "posts_user_idx", 0, "c", 0))
+	dbMock.ExpectQuery(regexp.QuoteMeta(`PRAGMA index_info("posts_user_idx")`)).
+		WillReturnRows(sqlmock.NewRows([]string{"seqno", "cid", "name"}).
+			AddRow(0, 1, "user_id"))
+	dbMock.ExpectQuery(regexp.QuoteMeta(`PRAGMA foreign_key_list("posts")`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seq", "table", "from", "to", "on_update", "on_delete", "match"}).
+			AddRow(0, 0, "users", "user_id", "id", "NO ACTION", "NO ACTION", "NONE"))
+
+	schema, err := SQLiteSchemaInspector{}.InspectSchema(db)
+	assert.Nil(t, err)
+
+	expected := version.Schema{Tables: []version.Table{{
+		Name: "posts",
+		Columns: []version.Column{
+			{Name: "id", Type: "INTEGER"},
+			{Name: "user_id", Type: "INTEGER", Nullable: true},
+		},
+		Indexes: []version.Index{
+			{Name: "posts_user_idx", Columns: []string{"user_id"}},
+		},
+		Constraints: []version.Constraint{
+			{Type: "PRIMARY KEY", Columns: []string{"id"}},
+			{Name: "posts_fk_0", Type: "FOREIGN KEY", Columns: []string{"user_id"}},
+		},
+	}}}
+	assert.True(t, expected.Equal(schema), "Unexpected schema %+v", schema)
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
@@ -0,0 +1,304 @@
+package version
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrMissingCreateSQL is returned by LoadSQLScheme when the directory
+// holds no create.sql script
+var ErrMissingCreateSQL = errors.New("versioned db: create.sql is missing")
+
+// ErrMissingUpdateSQL is returned by the OnUpdate of a NewSQLScheme
+// scheme when no script updates from the old version
+var ErrMissingUpdateSQL = errors.New("versioned db: update script is missing")
+
+// LoadSQLScheme returns a scheme of the given version and strategy
+// running the SQL scripts of dir: create.sql on OnCreate and
+// update_N.sql on OnUpdate(db, N). create.sql is read at once, update
+// scripts when they are needed. Scripts may hold several statements
+// separated by semicolons, which are executed in order. The scheme
+// implements SchemeChangeLog with the content of changelog_N.txt,
+// describing version N, when the file exists, and with the
+// "-- Description:" header of the script migrating to version N
+// otherwise, as parsed by ParseMigrationHeader. The header of
+// create.sql describes the scheme
+func LoadSQLScheme(dir string, version int, strategyName string) (Scheme, error) {
+	return LoadSQLSchemeFromFS(os.DirFS(dir), version, strategyName)
+}
+
+// LoadSQLSchemeFromFS is like LoadSQLScheme but reads the scripts from
+// the root of fsys, allowing them to be embedded in the binary:
+//
+//	//go:embed migrations
+//	var migrations embed.FS
+//
+//	sub, _ := fs.Sub(migrations, "migrations")
+//	scheme, err := version.LoadSQLSchemeFromFS(sub, 3, "table")
+func LoadSQLSchemeFromFS(fsys fs.FS, version int, strategyName string) (Scheme, error) {
+	if version < 1 {
+		return nil, ErrVersionBelowOne
+	}
+
+	create, err := fs.ReadFile(fsys, "create.sql")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrMissingCreateSQL
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &sqlFileScheme{fsys: fsys, version: version, strategy: strategyName, create: string(create)}, nil
+}
+
+type sqlFileScheme struct {
+	fsys     fs.FS
+	version  int
+	strategy string
+	create   string
+}
+
+func (s *sqlFileScheme) Version() SchemeVersion {
+	return SchemeVersion(s.version)
+}
+
+func (s *sqlFileScheme) VersionStrategy() string {
+	return s.strategy
+}
+
+func (s *sqlFileScheme) OnCreate(db *sql.DB) error {
+	return s.OnCreateContext(context.Background(), db)
+}
+
+func (s *sqlFileScheme) OnUpdate(db *sql.DB, oldVersion int) error {
+	return s.OnUpdateContext(context.Background(), db, oldVersion)
+}
+
+func (s *sqlFileScheme) OnCreateContext(ctx context.Context, db *sql.DB) error {
+	return execSQL(ctx, db, s.create)
+}
+
+func (s *sqlFileScheme) OnUpdateContext(ctx context.Context, db *sql.DB, oldVersion int) error {
+	update, err := fs.ReadFile(s.fsys, fmt.Sprintf("update_%d.sql", oldVersion))
+	if err != nil {
+		return err
+	}
+	return execSQL(ctx, db, string(update))
+}
+
+// OnCreateTx implements TxScheme
+func (s *sqlFileScheme) OnCreateTx(tx *sql.Tx) error {
+	return s.onCreateTx(context.Background(), tx)
+}
+
+// OnUpdateTx implements TxScheme
+func (s *sqlFileScheme) OnUpdateTx(tx *sql.Tx, oldVersion int) error {
+	return s.onUpdateTx(context.Background(), tx, oldVersion)
+}
+
+func (s *sqlFileScheme) onCreateTx(ctx context.Context, tx *sql.Tx) error {
+	return execScript(ctx, tx, s.create)
+}
+
+func (s *sqlFileScheme) onUpdateTx(ctx context.Context, tx *sql.Tx, oldVersion int) error {
+	update, err := fs.ReadFile(s.fsys, fmt.Sprintf("update_%d.sql", oldVersion))
+	if err != nil {
+		return err
+	}
+	return execScript(ctx, tx, string(update))
+}
+
+// Checksum implements ChecksumScheme hashing the update scripts run
+// before version. create.sql is left out since it changes with every
+// new version
+func (s *sqlFileScheme) Checksum(version int) (string, error) {
+	return updatesChecksum(version, func(oldVersion int) (string, error) {
+		update, err := fs.ReadFile(s.fsys, fmt.Sprintf("update_%d.sql", oldVersion))
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return string(update), err
+	})
+}
+
+// ChangeLog implements SchemeChangeLog reading changelog_N.txt for
+// version N, or the description header of the script migrating to N
+// when missing. create.sql migrates to version 1, update_N.sql to N+1
+func (s *sqlFileScheme) ChangeLog(version int) string {
+	changeLog, err := fs.ReadFile(s.fsys, fmt.Sprintf("changelog_%d.txt", version))
+	if err == nil {
+		return strings.TrimSpace(string(changeLog))
+	}
+	if version == 1 {
+		return s.Description()
+	}
+	update, err := fs.ReadFile(s.fsys, fmt.Sprintf("update_%d.sql", version-1))
+	if err != nil {
+		return ""
+	}
+	return ParseMigrationHeader(string(update)).Description()
+}
+
+// Description implements SchemeDescriber with the description header
+// of create.sql
+func (s *sqlFileScheme) Description() string {
+	return ParseMigrationHeader(s.create).Description()
+}
+
+// NewSQLScheme returns a scheme of the given version and strategy
+// running createSQL on OnCreate and updateSQLs[oldVersion] on
+// OnUpdate(db, oldVersion). Scripts may hold several statements
+// separated by semicolons
+func NewSQLScheme(version int, strategyName string, createSQL string, updateSQLs map[int]string) Scheme {
+	return &sqlScheme{version: version, strategy: strategyName, create: createSQL, updates: updateSQLs}
+}
+
+// NewQueryScheme returns a scheme of the given version and strategy
+// running createQuery on OnCreate and updateQueries[oldVersion] on
+// OnUpdate(db, oldVersion). Queries are split on the semicolons outside
+// of comments and quoted strings, trimmed, and executed in order,
+// skipping the empty ones. A failing statement is reported as a
+// *QueryExecutionError. It behaves as NewSQLScheme, under the name
+// migration files of queries are often known by
+func NewQueryScheme(version int, strategyName string, createQuery string, updateQueries map[int]string) Scheme {
+	return NewSQLScheme(version, strategyName, createQuery, updateQueries)
+}
+
+type sqlScheme struct {
+	version  int
+	strategy string
+	create   string
+	updates  map[int]string
+}
+
+func (s *sqlScheme) Version() SchemeVersion {
+	return SchemeVersion(s.version)
+}
+
+func (s *sqlScheme) VersionStrategy() string {
+	return s.strategy
+}
+
+func (s *sqlScheme) OnCreate(db *sql.DB) error {
+	return s.OnCreateContext(context.Background(), db)
+}
+
+func (s *sqlScheme) OnUpdate(db *sql.DB, oldVersion int) error {
+	return s.OnUpdateContext(context.Background(), db, oldVersion)
+}
+
+func (s *sqlScheme) OnCreateContext(ctx context.Context, db *sql.DB) error {
+	return execSQL(ctx, db, s.create)
+}
+
+func (s *sqlScheme) OnUpdateContext(ctx context.Context, db *sql.DB, oldVersion int) error {
+	update, ok := s.updates[oldVersion]
+	if !ok {
+		return fmt.Errorf("%w from version %d", ErrMissingUpdateSQL, oldVersion)
+	}
+	return execSQL(ctx, db, update)
+}
+
+// OnCreateTx implements TxScheme
+func (s *sqlScheme) OnCreateTx(tx *sql.Tx) error {
+	return s.onCreateTx(context.Background(), tx)
+}
+
+// OnUpdateTx implements TxScheme
+func (s *sqlScheme) OnUpdateTx(tx *sql.Tx, oldVersion int) error {
+	return s.onUpdateTx(context.Background(), tx, oldVersion)
+}
+
+func (s *sqlScheme) onCreateTx(ctx context.Context, tx *sql.Tx) error {
+	return execScript(ctx, tx, s.create)
+}
+
+func (s *sqlScheme) onUpdateTx(ctx context.Context, tx *sql.Tx, oldVersion int) error {
+	update, ok := s.updates[oldVersion]
+	if !ok {
+		return fmt.Errorf("%w from version %d", ErrMissingUpdateSQL, oldVersion)
+	}
+	return execScript(ctx, tx, update)
+}
+
+// Checksum implements ChecksumScheme hashing the update scripts run
+// before version
+func (s *sqlScheme) Checksum(version int) (string, error) {
+	return updatesChecksum(version, func(oldVersion int) (string, error) {
+		return s.updates[oldVersion], nil
+	})
+}
+
+// updatesChecksum hashes the update scripts from version one to
+// version, missing scripts being hashed as empty ones
+func updatesChecksum(version int, update func(oldVersion int) (string, error)) (string, error) {
+	h := sha256.New()
+	for oldVersion := 1; oldVersion < version; oldVersion++ {
+		script, err := update(oldVersion)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%d %d\n%s", oldVersion, len(script), script)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// QueryExecutionError is returned by the SQL schemes when a statement
+// of a script fails. Index is the position of Statement among the
+// statements of the script, starting at zero
+type QueryExecutionError struct {
+	Statement string
+	Index     int
+	Err       error
+}
+
+func (e *QueryExecutionError) Error() string {
+	return fmt.Sprintf("versioned db: statement %d failed: %v: %s", e.Index, e.Err, e.Statement)
+}
+
+func (e *QueryExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// sqlConn is implemented by *sql.DB and *sql.Tx
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// execSQL executes the statements of script in order, skipping those
+// holding only comments
+func execSQL(ctx context.Context, db *sql.DB, script string) error {
+	if db == nil {
+		return ErrNilDB
+	}
+	return execScript(ctx, db, script)
+}
+
+// execScript is like execSQL but executes on conn. The statements are
+// recorded by the SQLTrace of the migration, if any
+func execScript(ctx context.Context, conn sqlConn, script string) error {
+	trace := SQLTraceFromContext(ctx)
+	index := 0
+	for _, stmt := range splitSQL(script) {
+		if !hasCode(stmt) {
+			continue
+		}
+		stmt = strings.TrimSpace(stmt)
+		start := time.Now()
+		_, err := conn.ExecContext(ctx, stmt)
+		trace.Record(TracedStatement{Query: stmt, Start: start, Duration: time.Since(start), Err: err})
+		if err != nil {
+			return &QueryExecutionError{Statement: stmt, Index: index, Err: err}
+		}
+		index++
+	}
+	return nil
+}
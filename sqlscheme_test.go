@@ -0,0 +1,265 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func writeSQLFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadSQLScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dir := writeSQLFiles(t, map[string]string{
+		"create.sql":   "CREATE TABLE users (id int);\n-- the email\nCREATE INDEX users_id ON users (id);",
+		"update_1.sql": "ALTER TABLE users ADD email text;",
+	})
+
+	s, err := LoadSQLScheme(dir, 2, "fake")
+	assert.Nil(t, err, "LoadSQLScheme must not return error")
+	assert.Equal(t, SchemeVersion(2), s.Version())
+	assert.Equal(t, "fake", s.VersionStrategy())
+
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE INDEX users_id").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, s.OnCreate(db), "Every create statement must be executed")
+	assert.Nil(t, s.OnUpdate(db, 1), "update_1.sql must be executed")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestLoadSQLSchemeHeaders(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	dir := writeSQLFiles(t, map[string]string{
+		"create.sql":   "-- Description: Users and their emails\n-- Author: jane\nCREATE TABLE users (id int, email text);",
+		"update_1.sql": "-- Description: Add email to users\n\nALTER TABLE users ADD email text;",
+	})
+
+	s, err := LoadSQLScheme(dir, 2, "table")
+	assert.Nil(t, err, "LoadSQLScheme must not return error")
+	assert.Equal(t, "Users and their emails", schemeDescription(s), "Description must be read from the create.sql header")
+	changeLog, ok := asSchemeChangeLog(s)
+	assert.True(t, ok)
+	assert.Equal(t, "Users and their emails", changeLog.ChangeLog(1), "Version 1 must be described by create.sql")
+	assert.Equal(t, "Add email to users", changeLog.ChangeLog(2), "Version 2 must be described by update_1.sql")
+	assert.Empty(t, changeLog.ChangeLog(3))
+
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").
+		WithArgs(2, "update", sqlmock.AnyArg(), sqlmock.AnyArg(), "Add email to users").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	res, err := PersistScheme(db, s)
+	assert.Nil(t, err, "PersistScheme must not return error")
+	assert.Equal(t, "Users and their emails", res.Description, "Description must be reported in the result")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestLoadSQLSchemeMissingCreate(t *testing.T) {
+	dir := writeSQLFiles(t, map[string]string{"update_1.sql": "SELECT 1"})
+
+	_, err := LoadSQLScheme(dir, 1, "fake")
+	assert.Equal(t, ErrMissingCreateSQL, err)
+}
+
+func TestLoadSQLSchemeReadError(t *testing.T) {
+	dir := writeSQLFiles(t, nil)
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "create.sql"), 0o755))
+
+	_, err := LoadSQLScheme(dir, 1, "fake")
+	assert.NotNil(t, err, "Unreadable create.sql must be reported")
+	assert.NotEqual(t, ErrMissingCreateSQL, err)
+}
+
+func TestSQLSchemeMissingUpdate(t *testing.T) {
+	s, err := LoadSQLScheme(writeSQLFiles(t, map[string]string{"create.sql": "SELECT 1"}), 3, "fake")
+	assert.Nil(t, err)
+
+	err = s.OnUpdate(new(sql.DB), 2)
+	assert.True(t, errors.Is(err, fs.ErrNotExist), "Missing update script must be reported")
+}
+
+func TestSQLSchemeExecError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	s, err := LoadSQLScheme(writeSQLFiles(t, map[string]string{"create.sql": "CREATE TABLE a (id int); CREATE TABLE b (id int)"}), 1, "fake")
+	assert.Nil(t, err)
+
+	dbMock.ExpectExec("CREATE TABLE a").WillReturnError(someError)
+
+	assert.True(t, errors.Is(s.OnCreate(db), someError), "Exec error must be passed out")
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Statements after the failing one must not run. Err %q", err)
+	}
+}
+
+func TestLoadSQLSchemeFromFS(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	fsys := fstest.MapFS{
+		"create.sql":   {Data: []byte("CREATE TABLE users (id int)")},
+		"update_1.sql": {Data: []byte("ALTER TABLE users ADD email text")},
+	}
+
+	s, err := LoadSQLSchemeFromFS(fsys, 2, "fake")
+	assert.Nil(t, err, "LoadSQLSchemeFromFS must not return error")
+
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.Nil(t, s.OnUpdate(db, 1))
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+
+	_, err = LoadSQLSchemeFromFS(fstest.MapFS{}, 1, "fake")
+	assert.Equal(t, ErrMissingCreateSQL, err)
+}
+
+func TestNewSQLScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	s := NewSQLScheme(3, "fake", "CREATE TABLE a (id int); CREATE TABLE b (id int);", map[int]string{
+		1: "ALTER TABLE a ADD name text",
+		2: "",
+	})
+	assert.Equal(t, SchemeVersion(3), s.Version())
+	assert.Equal(t, "fake", s.VersionStrategy())
+
+	dbMock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("ALTER TABLE a ADD name").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, s.OnCreate(db))
+	assert.Nil(t, s.OnUpdate(db, 1))
+	assert.Nil(t, s.OnUpdate(db, 2), "Empty script must be a noop")
+
+	err := dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestNewSQLSchemeMissingUpdate(t *testing.T) {
+	s := NewSQLScheme(2, "fake", "", nil)
+
+	err := s.OnUpdate(new(sql.DB), 1)
+	assert.True(t, errors.Is(err, ErrMissingUpdateSQL), "Missing update key must be reported")
+}
+
+func TestNewSQLSchemeNilDB(t *testing.T) {
+	s := NewSQLScheme(1, "fake", "CREATE TABLE a (id int)", nil)
+
+	assert.Equal(t, ErrNilDB, s.OnCreate(nil))
+}
+
+func TestQueryExecutionError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	s := NewSQLScheme(1, "fake", "CREATE TABLE a (id int);\n\n-- index\nCREATE INDEX a_id ON a (id);\n", nil)
+
+	dbMock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE INDEX a_id").WillReturnError(someError)
+
+	err := s.OnCreate(db)
+	var queryErr *QueryExecutionError
+	if assert.True(t, errors.As(err, &queryErr), "QueryExecutionError must be returned") {
+		assert.Equal(t, 1, queryErr.Index, "Comments must not count as statements")
+		assert.Equal(t, "-- index\nCREATE INDEX a_id ON a (id)", queryErr.Statement)
+		assert.Equal(t, someError, queryErr.Err)
+	}
+}
+
+func TestSQLSchemeDollarQuotedBody(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	function := "CREATE FUNCTION touch() RETURNS trigger AS $body$\n" +
+		"BEGIN\n  NEW.updated_at := now();\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql"
+	s := NewSQLScheme(1, "fake", function+";\nCREATE TABLE a (id int, v text DEFAULT $$a;b$$)", nil)
+
+	dbMock.ExpectExec("CREATE FUNCTION touch").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.Nil(t, s.OnCreate(db), "Dollar quoted bodies must not be split")
+
+	err := dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+	assert.Equal(t, []string{function, "UPDATE t SET v = $1"}, splitSQL(function+";UPDATE t SET v = $1"))
+}
+
+func TestNewQueryScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	s := NewQueryScheme(2, "fake", "CREATE TABLE a (id int);\n;\n  CREATE INDEX a_id ON a (id);  ", map[int]string{
+		1: "ALTER TABLE a ADD name text; ALTER TABLE a ADD email text",
+	})
+	assert.Equal(t, SchemeVersion(2), s.Version())
+
+	dbMock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE INDEX a_id").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("ALTER TABLE a ADD name").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("ALTER TABLE a ADD email").WillReturnError(someError)
+
+	assert.Nil(t, s.OnCreate(db), "Empty statements must be skipped")
+	err := s.OnUpdate(db, 1)
+	var queryErr *QueryExecutionError
+	if assert.True(t, errors.As(err, &queryErr), "QueryExecutionError must be returned") {
+		assert.Equal(t, 1, queryErr.Index)
+		assert.Equal(t, "ALTER TABLE a ADD email text", queryErr.Statement)
+	}
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
@@ -0,0 +1,72 @@
+package version
+
+import (
+	"database/sql"
+)
+
+const defaultStateTable = "migration_state"
+
+// MigrationState is a key value store backed by a database table,
+// meant for migrations that must remember their progress across
+// restarts, such as the last processed batch of a data migration.
+//
+// The table is created on first use. The queries use $n placeholders
+// and the bytea type, as supported by PostgreSQL.
+type MigrationState struct {
+	// Table is the table the state is stored in,
+	// migration_state is used when it is empty
+	Table string
+}
+
+// Save stores value under key, replacing any previous value
+func (s *MigrationState) Save(db *sql.DB, key string, value []byte) error {
+	if db == nil {
+		return ErrNilDB
+	}
+
+	if err := s.createTable(db); err != nil {
+		return err
+	}
+
+	res, err := db.Exec("UPDATE "+s.table()+" SET value = $2 WHERE key = $1", key, value)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO "+s.table()+" (key, value) VALUES ($1, $2)", key, value)
+	return err
+}
+
+// Load returns the value stored under key, or nil if nothing was
+// saved under key yet
+func (s *MigrationState) Load(db *sql.DB, key string) ([]byte, error) {
+	if db == nil {
+		return nil, ErrNilDB
+	}
+
+	if err := s.createTable(db); err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	err := db.QueryRow("SELECT value FROM "+s.table()+" WHERE key = $1", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return value, err
+}
+
+func (s *MigrationState) table() string {
+	if s.Table == "" {
+		return defaultStateTable
+	}
+	return s.Table
+}
+
+func (s *MigrationState) createTable(db *sql.DB) error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS " + s.table() + " (key text PRIMARY KEY, value bytea)")
+	return err
+}
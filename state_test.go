@@ -0,0 +1,70 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMigrationStateSaveUpdate(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS migration_state").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("UPDATE migration_state").WithArgs("offset", []byte("42")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := new(MigrationState).Save(db, "offset", []byte("42"))
+	assert.Nil(t, err, "Save must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestMigrationStateSaveInsert(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS state").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("UPDATE state").WithArgs("offset", []byte("1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("INSERT INTO state").WithArgs("offset", []byte("1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := (&MigrationState{Table: "state"}).Save(db, "offset", []byte("1"))
+	assert.Nil(t, err, "Save must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestMigrationStateLoad(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS migration_state").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT value FROM migration_state").WithArgs("offset").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("42")))
+
+	value, err := new(MigrationState).Load(db, "offset")
+	assert.Nil(t, err, "Load must not return error")
+	assert.Equal(t, []byte("42"), value, "Saved value not returned")
+}
+
+func TestMigrationStateLoadMissingKey(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS migration_state").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT value FROM migration_state").WithArgs("offset").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+
+	value, err := new(MigrationState).Load(db, "offset")
+	assert.Nil(t, err, "Loading a missing key must not return error")
+	assert.Nil(t, value, "Missing key must load nil")
+}
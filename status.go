@@ -0,0 +1,51 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// IsMigrationNeeded tells whether PersistScheme, given opts, would
+// migrate db to scheme, without opening a transaction nor changing any
+// state. The migration is planned as PersistScheme plans it, so
+// recreations and downgrades are reported as needed, and the errors
+// PersistScheme returns before migrating, such as for a nil db, a nil
+// or invalid scheme, an unregistered strategy or a database ahead of
+// the scheme under the FuturePolicy, are returned
+func IsMigrationNeeded(db *sql.DB, scheme Scheme, opts ...Option) (bool, error) {
+	return DefaultRegistry.IsMigrationNeeded(db, scheme, opts...)
+}
+
+// IsMigrationNeeded is like the package level IsMigrationNeeded but
+// resolves the strategy of scheme in r
+func (r *Registry) IsMigrationNeeded(db *sql.DB, scheme Scheme, opts ...Option) (bool, error) {
+	strategy, version, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return false, err
+	}
+	plan, err := planMigration(context.Background(), strategy, db, version, scheme, newOptions(opts))
+	if err != nil {
+		return false, err
+	}
+	return plan.steps != nil, nil
+}
+
+// CurrentVersion returns the version recorded in db by the strategy
+// registered by strategyName, zero for databases never migrated
+func CurrentVersion(db *sql.DB, strategyName string) (int, error) {
+	return DefaultRegistry.CurrentVersion(db, strategyName)
+}
+
+// CurrentVersion is like the package level CurrentVersion but looks
+// strategyName up in r
+func (r *Registry) CurrentVersion(db *sql.DB, strategyName string) (int, error) {
+	if db == nil {
+		return 0, ErrNilDB
+	}
+	strategy := r.lookup(strategyName)
+	if strategy == nil {
+		return 0, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, strategyName)
+	}
+	return strategyVersion(context.Background(), strategy, db)
+}
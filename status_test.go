@@ -0,0 +1,106 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsMigrationNeeded(t *testing.T) {
+	for dbVersion, needed := range map[int]bool{0: true, 1: true, 2: false, 3: false} {
+		setup(t)
+
+		strategy.On("Version", db).Return(dbVersion, nil)
+		scheme.
+			On("Version").Return(2).
+			On("VersionStrategy").Return("fake")
+
+		got, err := IsMigrationNeeded(db, scheme, WithFutureDatabasePolicy(FuturePolicyIgnore))
+		assert.Nil(t, err, "IsMigrationNeeded must not return error")
+		assert.Equal(t, needed, got, "Wrong answer for database at version %d", dbVersion)
+
+		err = dbMock.ExpectationsWereMet()
+		if err != nil {
+			t.Errorf("No statement must be run. Err %q", err)
+		}
+		tearsDown(t)
+	}
+}
+
+func TestIsMigrationNeededAgreesWithPersistScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(3, nil)
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+	downgrading := new(downgradeSchemeMock)
+	downgrading.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	_, err := IsMigrationNeeded(db, scheme)
+	assert.True(t, errors.Is(err, ErrVersionDowngrade), "A database ahead of the scheme must be reported as PersistScheme does")
+
+	needed, err := IsMigrationNeeded(db, downgrading)
+	assert.Nil(t, err, "IsMigrationNeeded must not return error")
+	assert.True(t, needed, "A pending downgrade must be reported as needed")
+
+	needed, err = IsMigrationNeeded(db, NewIdempotentScheme(scheme, 3))
+	assert.Nil(t, err, "IsMigrationNeeded must not return error")
+	assert.True(t, needed, "A recreation must be reported as needed")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("No statement must be run. Err %q", err)
+	}
+}
+
+func TestIsMigrationNeededErrors(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, err := IsMigrationNeeded(nil, scheme)
+	assert.Equal(t, ErrNilDB, err)
+
+	_, err = IsMigrationNeeded(db, nil)
+	assert.Equal(t, ErrNilScheme, err)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("not_registered")
+	_, err = IsMigrationNeeded(db, scheme)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Unregistered strategy must be reported")
+}
+
+func TestIsMigrationNeededVersionError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(0, someError)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := IsMigrationNeeded(db, scheme)
+	assert.Equal(t, someError, err)
+}
+
+func TestCurrentVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(4, nil)
+
+	v, err := CurrentVersion(db, "fake")
+	assert.Nil(t, err, "CurrentVersion must not return error")
+	assert.Equal(t, 4, v)
+
+	_, err = CurrentVersion(nil, "fake")
+	assert.Equal(t, ErrNilDB, err)
+
+	_, err = CurrentVersion(db, "not_registered")
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Unregistered strategy must be reported")
+}
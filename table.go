@@ -0,0 +1,326 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultVersionTable = "schema_version"
+
+// NewTableStrategy returns a strategy storing the version as the single
+// row of tableName, schema_version when empty. Every version recorded
+// is also appended to the tableName_history table, making the strategy
+// a HistoryStrategy and a TagStrategy. tableName may be qualified by its schema, the
+// current schema is used otherwise. The tables are created by the first
+// SetVersion, or by WarmUp, reading a database without them reports
+// version zero.
+//
+// The queries use $n placeholders, as supported by PostgreSQL.
+func NewTableStrategy(tableName string) Strategy {
+	if tableName == "" {
+		tableName = defaultVersionTable
+	}
+	return &tableStrategy{table: tableName}
+}
+
+type tableStrategy struct {
+	table string
+	// namespace, when set, selects the row of the version table, which
+	// gains a namespace column
+	namespace string
+}
+
+func (s *tableStrategy) withNamespace(namespace string) Strategy {
+	return &tableStrategy{table: s.table, namespace: namespace}
+}
+
+func (s *tableStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *tableStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+// VersionContext returns the recorded version, zero if none was
+// recorded yet
+func (s *tableStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	return s.readVersion(ctx, db)
+}
+
+// SetVersionContext replaces the recorded version, inserting the row
+// when the table is empty, and appends it to the history table. All
+// happen in one transaction so the table never holds more than one row
+func (s *tableStrategy) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	if err := s.ensureTables(ctx, db); err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = s.writeVersion(ctx, tx, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// VersionTx is like VersionContext but reads within tx
+func (s *tableStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.versionTx(context.Background(), tx)
+}
+
+// SetVersionTx is like SetVersionContext but writes within tx
+func (s *tableStrategy) SetVersionTx(tx *sql.Tx, version int) error {
+	return s.setVersionTx(context.Background(), tx, version)
+}
+
+func (s *tableStrategy) versionTx(ctx context.Context, tx *sql.Tx) (int, error) {
+	return s.readVersion(ctx, tx)
+}
+
+func (s *tableStrategy) setVersionTx(ctx context.Context, tx *sql.Tx, version int) error {
+	if err := s.ensureTables(ctx, tx); err != nil {
+		return err
+	}
+	return s.writeVersion(ctx, tx, version)
+}
+
+func (s *tableStrategy) readVersion(ctx context.Context, conn sqlConn) (int, error) {
+	exists, err := s.tableExists(ctx, conn, s.table)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version int
+	err = conn.QueryRowContext(ctx, "SELECT version FROM "+s.table+s.where(1), s.whereArgs()...).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
+
+// writeVersion records version and its history entry within tx
+func (s *tableStrategy) writeVersion(ctx context.Context, tx *sql.Tx, version int) error {
+	var current int
+	err := tx.QueryRowContext(ctx, "SELECT version FROM "+s.table+s.where(1), s.whereArgs()...).Scan(&current)
+	switch err {
+	case nil:
+		_, err = tx.ExecContext(ctx, "UPDATE "+s.table+" SET version = $1"+s.where(2),
+			append([]interface{}{version}, s.whereArgs()...)...)
+	case sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+s.table+" ("+s.columns("version")+") VALUES ("+s.values(1)+")",
+			append([]interface{}{version}, s.whereArgs()...)...)
+	}
+	if err != nil {
+		return err
+	}
+
+	var duration time.Duration
+	if info, ok := MigrationInfoFromContext(ctx); ok {
+		duration = time.Since(info.StartedAt)
+	}
+	var description string
+	if scheme, ok := ctx.Value(schemeKey{}).(Scheme); ok {
+		if changeLog, ok := asSchemeChangeLog(scheme); ok {
+			description = changeLog.ChangeLog(version)
+		}
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+s.historyTable()+" ("+s.columns("version, action, applied_at, duration, description")+") "+
+		"VALUES ("+s.values(5)+")", append([]interface{}{version, historyAction(current, version), time.Now(), int64(duration), description},
+		s.whereArgs()...)...)
+	return err
+}
+
+// VersionHistory returns the recorded versions, oldest first
+func (s *tableStrategy) VersionHistory(db *sql.DB) ([]VersionEntry, error) {
+	exists, err := s.tableExists(context.Background(), db, s.historyTable())
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, action, applied_at, duration, description FROM "+s.historyTable()+s.where(1)+" ORDER BY id",
+		s.whereArgs()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []VersionEntry
+	for rows.Next() {
+		var (
+			entry    VersionEntry
+			duration int64
+		)
+		if err = rows.Scan(&entry.Version, &entry.Action, &entry.AppliedAt, &duration, &entry.Description); err != nil {
+			return nil, err
+		}
+		entry.Duration = time.Duration(duration)
+		history = append(history, entry)
+	}
+	return history, rows.Err()
+}
+
+// TagVersion labels the last history entry of version with tag, after
+// removing tag from the entries it labelled before
+func (s *tableStrategy) TagVersion(db *sql.DB, version int, tag string) error {
+	exists, err := s.tableExists(context.Background(), db, s.historyTable())
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrVersionNotRecorded, version)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE "+s.historyTable()+" SET tag = NULL WHERE tag = $1"+s.and(2), append([]interface{}{tag}, s.whereArgs()...)...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	res, err := tx.Exec("UPDATE "+s.historyTable()+" SET tag = $1 WHERE id = (SELECT max(id) FROM "+s.historyTable()+
+		" WHERE version = $2"+s.and(3)+")", append([]interface{}{tag, version}, s.whereArgs()...)...)
+	if err == nil {
+		var n int64
+		if n, err = res.RowsAffected(); err == nil && n == 0 {
+			err = fmt.Errorf("%w: %d", ErrVersionNotRecorded, version)
+		}
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// LookupVersionByTag returns the version of the history entry labelled
+// with tag
+func (s *tableStrategy) LookupVersionByTag(db *sql.DB, tag string) (int, error) {
+	exists, err := s.tableExists(context.Background(), db, s.historyTable())
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("%w %q", ErrTagNotFound, tag)
+	}
+
+	var version int
+	err = db.QueryRow("SELECT version FROM "+s.historyTable()+" WHERE tag = $1"+s.and(2)+" ORDER BY id DESC LIMIT 1",
+		append([]interface{}{tag}, s.whereArgs()...)...).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("%w %q", ErrTagNotFound, tag)
+	}
+	return version, err
+}
+
+func (s *tableStrategy) CreateVersionTable(db *sql.DB) error {
+	return s.createTable(context.Background(), db)
+}
+
+func (s *tableStrategy) VersionTableExists(db *sql.DB) (bool, error) {
+	return s.tableExists(context.Background(), db, s.table)
+}
+
+// tableExists looks table up in its schema, the current one when table
+// is not qualified
+func (s *tableStrategy) tableExists(ctx context.Context, conn sqlConn, table string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1)"
+	args := []interface{}{table}
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		query = "SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2)"
+		args = []interface{}{schema, name}
+	}
+
+	var exists bool
+	err := conn.QueryRowContext(ctx, query, args...).Scan(&exists)
+	return exists, err
+}
+
+// ensureTables creates the tables unless they exist. The history table
+// is created last, so both exist when it does
+func (s *tableStrategy) ensureTables(ctx context.Context, conn sqlConn) error {
+	exists, err := s.tableExists(ctx, conn, s.historyTable())
+	if err != nil || exists {
+		return err
+	}
+	return s.createTable(ctx, conn)
+}
+
+// historyTable is named after the version table, schema_version_history
+// for the default one
+func (s *tableStrategy) historyTable() string {
+	return s.table + "_history"
+}
+
+func (s *tableStrategy) createTable(ctx context.Context, db sqlConn) error {
+	var namespace string
+	if s.namespace != "" {
+		namespace = "namespace text NOT NULL, "
+	}
+	versionColumns := namespace + "version integer NOT NULL"
+	if s.namespace != "" {
+		versionColumns += ", PRIMARY KEY (namespace)"
+	}
+
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+" ("+versionColumns+")")
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.historyTable()+" (id serial PRIMARY KEY, "+namespace+
+		"version integer NOT NULL, action text NOT NULL, applied_at timestamp NOT NULL, duration bigint NOT NULL, "+
+		"description text NOT NULL DEFAULT '', tag text)")
+	return err
+}
+
+// where returns the clause selecting the rows of the namespace, using
+// the nth placeholder, empty when no namespace is set
+func (s *tableStrategy) where(n int) string {
+	if s.namespace == "" {
+		return ""
+	}
+	return fmt.Sprintf(" WHERE namespace = $%d", n)
+}
+
+// and is like where but extends a WHERE clause of the query
+func (s *tableStrategy) and(n int) string {
+	if s.namespace == "" {
+		return ""
+	}
+	return fmt.Sprintf(" AND namespace = $%d", n)
+}
+
+func (s *tableStrategy) whereArgs() []interface{} {
+	if s.namespace == "" {
+		return nil
+	}
+	return []interface{}{s.namespace}
+}
+
+// columns appends the namespace column to the inserted columns
+func (s *tableStrategy) columns(columns string) string {
+	if s.namespace == "" {
+		return columns
+	}
+	return columns + ", namespace"
+}
+
+// values returns the placeholders of the n inserted columns, followed
+// by the one of the namespace
+func (s *tableStrategy) values(n int) string {
+	if s.namespace != "" {
+		n++
+	}
+	values := "$1"
+	for i := 2; i <= n; i++ {
+		values += fmt.Sprintf(", $%d", i)
+	}
+	return values
+}
@@ -0,0 +1,251 @@
+package version
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// expectTableLookup expects the information_schema lookup of table in
+// the current schema
+func expectTableLookup(table string, exists bool) {
+	dbMock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema\\(\\) AND table_name").
+		WithArgs(table).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+}
+
+// expectVersionTables expects the version tables to be created on the
+// first write
+func expectVersionTables(table string) {
+	expectTableLookup(table+"_history", false)
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS " + table + " \\(version integer NOT NULL\\)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE TABLE IF NOT EXISTS " + table + "_history").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestTableStrategyVersionWithoutTable(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectTableLookup("versions", false)
+
+	v, err := NewTableStrategy("versions").Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 0, v, "Missing table must report version zero")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategyEmptyTable(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectTableLookup("versions", true)
+	dbMock.ExpectQuery("SELECT version FROM versions").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	v, err := NewTableStrategy("versions").Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 0, v, "Empty table must report version zero")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategyCreatesTablesOnce(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectVersionTables("schema_version")
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO schema_version \\(version\\)").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	strategy := NewTableStrategy("")
+	assert.Nil(t, strategy.SetVersion(db, 1), "SetVersion must not return error")
+	assert.Nil(t, strategy.SetVersion(db, 2), "Existing tables must not be created again")
+
+	err := dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategyQualifiedTable(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM information_schema.tables WHERE table_schema = \\$1 AND table_name = \\$2\\)").
+		WithArgs("app", "versions").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := NewTableStrategy("app.versions").(TableCreator).VersionTableExists(db)
+	assert.Nil(t, err, "VersionTableExists must not return error")
+	assert.True(t, exists, "The table must be looked up in its schema")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategyVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(7))
+
+	v, err := NewTableStrategy("").Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 7, v, "Stored version must be returned")
+}
+
+func TestTableStrategySetVersionUpdates(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectVersionTables("schema_version")
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").
+		WithArgs(3, "update", sqlmock.AnyArg(), sqlmock.AnyArg(), "").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	err := NewTableStrategy("").SetVersion(db, 3)
+	assert.Nil(t, err, "SetVersion must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategySetVersionInserts(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectVersionTables("schema_version")
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO schema_version \\(version\\)").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").
+		WithArgs(1, "create", sqlmock.AnyArg(), sqlmock.AnyArg(), "").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	err := NewTableStrategy("").SetVersion(db, 1)
+	assert.Nil(t, err, "SetVersion must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategySetVersionError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	expectVersionTables("schema_version")
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WillReturnError(someError)
+	dbMock.ExpectRollback()
+
+	err := NewTableStrategy("").SetVersion(db, 2)
+	assert.Equal(t, someError, err, "Update error must be passed out")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTableStrategyVersionHistory(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	applied := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	Register("table", NewTableStrategy(""))
+
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version, action, applied_at, duration, description FROM schema_version_history").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "action", "applied_at", "duration", "description"}).
+			AddRow(1, "create", applied, int64(time.Second), "").
+			AddRow(2, "update", applied, int64(0), "Add email"))
+
+	history, err := GetMigrationHistory(db, "table")
+	assert.Nil(t, err, "GetMigrationHistory must not return error")
+	assert.Equal(t, []VersionEntry{
+		{Version: 1, Action: "create", AppliedAt: applied, Duration: time.Second},
+		{Version: 2, Action: "update", AppliedAt: applied, Description: "Add email"},
+	}, history)
+}
+
+func TestGetMigrationHistoryNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, err := GetMigrationHistory(db, "fake")
+	assert.Equal(t, ErrHistoryNotSupported, err)
+}
+
+func TestTableStrategyRecordsChangeLog(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	s, err := LoadSQLSchemeFromFS(fstest.MapFS{
+		"create.sql":      {Data: []byte("SELECT 1")},
+		"update_1.sql":    {Data: []byte("ALTER TABLE users ADD email text")},
+		"changelog_2.txt": {Data: []byte("Add email to users\n")},
+	}, 2, "table")
+	assert.Nil(t, err)
+
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("ALTER TABLE users ADD email").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectExec("UPDATE schema_version SET version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").
+		WithArgs(2, "update", sqlmock.AnyArg(), sqlmock.AnyArg(), "Add email to users").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	_, err = PersistScheme(db, s)
+	assert.Nil(t, err, "PersistScheme must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
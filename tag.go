@@ -0,0 +1,138 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the version tag functions, to be checked with
+// errors.Is
+var (
+	ErrTagsNotSupported   = errors.New("versioned db: strategy does not support version tags")
+	ErrEmptyTag           = errors.New("versioned db: tag is empty")
+	ErrTagNotFound        = errors.New("versioned db: tag not found")
+	ErrVersionNotRecorded = errors.New("versioned db: version not recorded")
+)
+
+// TagStrategy is implemented by strategies able to label the versions
+// they recorded. The strategy created by NewTableStrategy implements it,
+// storing tags in its history table
+type TagStrategy interface {
+	Strategy
+	// TagVersion labels the last record of version with tag, which is
+	// moved over from any version it labelled before
+	TagVersion(db *sql.DB, version int, tag string) error
+	// LookupVersionByTag returns the version labelled with tag
+	LookupVersionByTag(db *sql.DB, tag string) (int, error)
+}
+
+// TagVersion labels version, as recorded by the strategy registered
+// by strategyName, with tag, such as "release-1.0". A tag labels a
+// single version, tagging another version moves it
+func TagVersion(db *sql.DB, strategyName string, version int, tag string) error {
+	return DefaultRegistry.TagVersion(db, strategyName, version, tag)
+}
+
+// LookupVersionByTag returns the version labelled with tag by
+// TagVersion, wrapping ErrTagNotFound when no version is
+func LookupVersionByTag(db *sql.DB, strategyName, tag string) (int, error) {
+	return DefaultRegistry.LookupVersionByTag(db, strategyName, tag)
+}
+
+// RollbackToTag migrates db down to the version labelled with tag, by
+// calling the OnDowngrade of scheme, which must implement
+// SchemeWithDowngrade, and records that version, in a single
+// transaction rolled back if either fails. Databases already at the
+// tagged version are left untouched, those behind it are not migrated
+// and make RollbackToTag return an error
+func RollbackToTag(db *sql.DB, scheme Scheme, tag string) error {
+	return DefaultRegistry.RollbackToTag(db, scheme, tag)
+}
+
+// TagVersion is like the package level TagVersion but looks
+// strategyName up in r
+func (r *Registry) TagVersion(db *sql.DB, strategyName string, version int, tag string) error {
+	s, err := r.tagStrategy(db, strategyName, tag)
+	if err != nil {
+		return err
+	}
+	return s.TagVersion(db, version, tag)
+}
+
+// LookupVersionByTag is like the package level LookupVersionByTag but
+// looks strategyName up in r
+func (r *Registry) LookupVersionByTag(db *sql.DB, strategyName, tag string) (int, error) {
+	s, err := r.tagStrategy(db, strategyName, tag)
+	if err != nil {
+		return 0, err
+	}
+	return s.LookupVersionByTag(db, tag)
+}
+
+// RollbackToTag is like the package level RollbackToTag but resolves
+// the strategy of scheme in r
+func (r *Registry) RollbackToTag(db *sql.DB, scheme Scheme, tag string) error {
+	strategy, _, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return err
+	}
+	version, err := r.LookupVersionByTag(db, scheme.VersionStrategy(), tag)
+	if err != nil {
+		return err
+	}
+	s, ok := asDowngradeScheme(scheme)
+	if !ok {
+		return ErrDowngradeNotSupported
+	}
+
+	dbVersion, err := strategyVersion(context.Background(), strategy, db)
+	if err != nil {
+		return err
+	}
+	if dbVersion == version {
+		return nil
+	}
+	if dbVersion < version {
+		return fmt.Errorf("versioned db: database at version %d, behind tag %q at version %d", dbVersion, tag, version)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = s.OnDowngrade(db, version); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%w: %w", ErrMigrationFailed, err)
+	}
+	if err = strategySetVersion(withForcedVersion(context.Background()), strategy, db, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	logf("rolled back to tag %q at version %d", tag, version)
+	return tx.Commit()
+}
+
+func (r *Registry) tagStrategy(db *sql.DB, strategyName, tag string) (TagStrategy, error) {
+	var strategy Strategy
+
+	if db == nil {
+		return nil, ErrNilDB
+	}
+
+	if tag == "" {
+		return nil, ErrEmptyTag
+	}
+
+	if strategy = r.lookup(strategyName); strategy == nil {
+		return nil, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, strategyName)
+	}
+
+	s, ok := strategy.(TagStrategy)
+	if !ok {
+		return nil, ErrTagsNotSupported
+	}
+	return s, nil
+}
@@ -0,0 +1,177 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTagVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE schema_version_history SET tag = NULL WHERE tag = \\$1").
+		WithArgs("release-1.0").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("UPDATE schema_version_history SET tag = \\$1 WHERE id = \\(SELECT max\\(id\\) FROM schema_version_history WHERE version = \\$2\\)").
+		WithArgs("release-1.0", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	err := TagVersion(db, "table", 2, "release-1.0")
+	assert.Nil(t, err, "TagVersion must not return error")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTagVersionNotRecorded(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("UPDATE schema_version_history SET tag = NULL").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("UPDATE schema_version_history SET tag = \\$1").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectRollback()
+
+	err := TagVersion(db, "table", 7, "release-1.0")
+	assert.True(t, errors.Is(err, ErrVersionNotRecorded), "ErrVersionNotRecorded must be returned for versions never recorded")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestLookupVersionByTag(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version_history WHERE tag = \\$1 ORDER BY id DESC LIMIT 1").
+		WithArgs("release-1.0").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	expectTableLookup("schema_version_history", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version_history WHERE tag = \\$1").
+		WithArgs("hotfix-2.3.1").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	v, err := LookupVersionByTag(db, "table", "release-1.0")
+	assert.Nil(t, err, "LookupVersionByTag must not return error")
+	assert.Equal(t, 2, v, "Version of the tag must be returned")
+
+	_, err = LookupVersionByTag(db, "table", "hotfix-2.3.1")
+	assert.True(t, errors.Is(err, ErrTagNotFound), "ErrTagNotFound must be returned for unknown tags")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTagVersionInvalidInput(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	assert.True(t, errors.Is(TagVersion(nil, "fake", 1, "v1"), ErrNilDB), "ErrNilDB must be returned when db is nil")
+	assert.True(t, errors.Is(TagVersion(db, "fake", 1, ""), ErrEmptyTag), "ErrEmptyTag must be returned for empty tags")
+	assert.True(t, errors.Is(TagVersion(db, "not_registered", 1, "v1"), ErrUnknownStrategy), "ErrUnknownStrategy must be returned for unknown strategies")
+	assert.True(t, errors.Is(TagVersion(db, "fake", 1, "v1"), ErrTagsNotSupported), "ErrTagsNotSupported must be returned for strategies without tags")
+}
+
+func TestRollbackToTag(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	tagged := new(tagStrategyMock)
+	Register("tagged", tagged)
+	downgrading := new(downgradeSchemeMock)
+
+	tagged.
+		On("LookupVersionByTag", db, "release-1.0").Return(2, nil).
+		On("Version", db).Return(4, nil).
+		On("SetVersion", db, 2).Return(nil)
+	downgrading.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("tagged").
+		On("OnDowngrade", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := RollbackToTag(db, downgrading, "release-1.0")
+	assert.Nil(t, err, "RollbackToTag must not return error")
+
+	tagged.AssertExpectations(t)
+	downgrading.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestRollbackToTagDowngradeError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	tagged := new(tagStrategyMock)
+	Register("tagged", tagged)
+	downgrading := new(downgradeSchemeMock)
+
+	tagged.
+		On("LookupVersionByTag", db, "release-1.0").Return(2, nil).
+		On("Version", db).Return(4, nil)
+	downgrading.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("tagged").
+		On("OnDowngrade", db, 2).Return(someError)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	err := RollbackToTag(db, downgrading, "release-1.0")
+	assert.True(t, errors.Is(err, someError), "OnDowngrade error must be returned")
+	assert.True(t, errors.Is(err, ErrMigrationFailed), "OnDowngrade error must be wrapped with ErrMigrationFailed")
+	tagged.AssertNotCalled(t, "SetVersion", db, 2)
+}
+
+func TestRollbackToTagNotSupported(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	tagged := new(tagStrategyMock)
+	Register("tagged", tagged)
+
+	tagged.On("LookupVersionByTag", db, "release-1.0").Return(2, nil)
+	scheme.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("tagged")
+
+	assert.Equal(t, ErrDowngradeNotSupported, RollbackToTag(db, scheme, "release-1.0"))
+}
+
+// Stubs
+/////////////////////////////////////////////////////
+
+type tagStrategyMock struct {
+	versionStrategyMock
+}
+
+func (m *tagStrategyMock) TagVersion(db *sql.DB, version int, tag string) error {
+	return m.Called(db, version, tag).Error(0)
+}
+
+func (m *tagStrategyMock) LookupVersionByTag(db *sql.DB, tag string) (int, error) {
+	args := m.Called(db, tag)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,76 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrTargetVersionOutOfRange is returned by PersistSchemeToVersion when
+// the target version is below one or above the scheme version
+var ErrTargetVersionOutOfRange = errors.New("versioned db: target version out of range")
+
+// PersistSchemeToVersion is like PersistScheme but updates db no
+// further than targetVersion, which must be between one and the scheme
+// version, allowing schemes to be rolled out in stages. Databases
+// already at or past targetVersion are left untouched. A database that
+// must be created is still created at the scheme version, as OnCreate
+// builds the whole scheme
+func PersistSchemeToVersion(db *sql.DB, scheme Scheme, targetVersion int, opts ...Option) (MigrationResult, error) {
+	return DefaultRegistry.PersistSchemeToVersion(db, scheme, targetVersion, opts...)
+}
+
+// PersistSchemeToVersion is like the package level
+// PersistSchemeToVersion but resolves the strategy of scheme in r
+func (r *Registry) PersistSchemeToVersion(db *sql.DB, scheme Scheme, targetVersion int, opts ...Option) (MigrationResult, error) {
+	strategy, version, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+	if targetVersion < 1 || targetVersion > version {
+		return MigrationResult{}, fmt.Errorf("%w: got %d, scheme at version %d", ErrTargetVersionOutOfRange, targetVersion, version)
+	}
+
+	o := newOptions(opts)
+	o.targetVersion = targetVersion
+	logf("using strategy %q for scheme version %d, target version %d", scheme.VersionStrategy(), version, targetVersion)
+	return persistSchemeInternal(context.Background(), strategy, db, version, scheme, o)
+}
+
+// PersistSchemeStep is like PersistScheme but runs at most one OnUpdate
+// call, advancing db by a single version, so callers can checkpoint
+// between versions:
+//
+//	for {
+//		advanced, err := version.PersistSchemeStep(db, scheme)
+//		if !advanced || err != nil {
+//			break
+//		}
+//	}
+//
+// Databases that must be created are created at the scheme version in
+// one step. advanced is false when db already is at or ahead of the
+// scheme version, which is not an error unless another FuturePolicy is
+// passed in opts
+func PersistSchemeStep(db *sql.DB, scheme Scheme, opts ...Option) (advanced bool, err error) {
+	return DefaultRegistry.PersistSchemeStep(db, scheme, opts...)
+}
+
+// PersistSchemeStep is like the package level PersistSchemeStep but
+// resolves the strategy of scheme in r
+func (r *Registry) PersistSchemeStep(db *sql.DB, scheme Scheme, opts ...Option) (advanced bool, err error) {
+	strategy, version, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return false, err
+	}
+
+	opts = append([]Option{WithFutureDatabasePolicy(FuturePolicyIgnore)}, opts...)
+	o := newOptions(opts)
+	o.singleStep = true
+	res, err := persistSchemeInternal(context.Background(), strategy, db, version, scheme, o)
+	if err != nil {
+		return false, err
+	}
+	return res.Action != ActionNoop, nil
+}
@@ -0,0 +1,127 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistSchemeToVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil).
+		On("SetVersion", db, 3).Return(nil)
+
+	scheme.
+		On("Version").Return(5).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).
+		On("OnUpdate", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	res, err := PersistSchemeToVersion(db, scheme, 3)
+	assert.Nil(t, err, "PersistSchemeToVersion must not return error")
+	assert.Equal(t, MigrationResult{Action: ActionUpdate, FromVersion: 1, ToVersion: 3}, res)
+
+	strategy.AssertExpectations(t)
+	scheme.AssertNotCalled(t, "OnUpdate", db, 3)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemeToVersionReached(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(4, nil)
+	scheme.
+		On("Version").Return(5).
+		On("VersionStrategy").Return("fake")
+
+	res, err := PersistSchemeToVersion(db, scheme, 3)
+	assert.Nil(t, err, "Databases past the target must be left untouched")
+	assert.Equal(t, ActionNoop, res.Action)
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemeToVersionOutOfRange(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	scheme.
+		On("Version").Return(2).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistSchemeToVersion(db, scheme, 0)
+	assert.True(t, errors.Is(err, ErrTargetVersionOutOfRange), "Target below one must be rejected")
+
+	_, err = PersistSchemeToVersion(db, scheme, 3)
+	assert.True(t, errors.Is(err, ErrTargetVersionOutOfRange), "Target above the scheme version must be rejected")
+}
+
+func TestPersistSchemeStep(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).Once().
+		On("SetVersion", db, 2).Return(nil).
+		On("Version", db).Return(2, nil).Once().
+		On("SetVersion", db, 3).Return(nil).
+		On("Version", db).Return(3, nil).Once()
+
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).Once().
+		On("OnUpdate", db, 2).Return(nil).Once()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	steps := 0
+	for {
+		advanced, err := PersistSchemeStep(db, scheme)
+		assert.Nil(t, err, "PersistSchemeStep must not return error")
+		if !advanced || err != nil {
+			break
+		}
+		steps++
+	}
+	assert.Equal(t, 2, steps, "One version must be advanced per call")
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	err := dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemeStepDatabaseAhead(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(4, nil)
+	scheme.
+		On("Version").Return(3).
+		On("VersionStrategy").Return("fake")
+
+	advanced, err := PersistSchemeStep(db, scheme)
+	assert.Nil(t, err, "Database ahead must not be an error")
+	assert.False(t, advanced)
+}
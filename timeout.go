@@ -0,0 +1,116 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOperationTimeout is returned by strategies created by
+// NewTimeoutStrategy when a call exceeds its deadline. The context error
+// is wrapped along with it
+var ErrOperationTimeout = errors.New("versioned db: strategy operation timed out")
+
+// NewTimeoutStrategy wraps inner bounding each Version call by
+// readTimeout and each SetVersion call by writeTimeout. A zero timeout
+// disables the bound. When inner implements ContextStrategy the
+// deadline is passed down to it, otherwise the call is abandoned, still
+// running, once the deadline is exceeded. The returned strategy
+// implements TxStrategy when inner does, bounding VersionTx and
+// SetVersionTx the same way
+func NewTimeoutStrategy(inner Strategy, readTimeout, writeTimeout time.Duration) Strategy {
+	s := &timeoutStrategy{inner: inner, readTimeout: readTimeout, writeTimeout: writeTimeout}
+	if inner, ok := inner.(TxStrategy); ok {
+		return &timeoutTxStrategy{timeoutStrategy: s, txInner: inner}
+	}
+	return s
+}
+
+type timeoutStrategy struct {
+	inner        Strategy
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// timeoutTxStrategy is the timeoutStrategy of a TxStrategy
+type timeoutTxStrategy struct {
+	*timeoutStrategy
+	txInner TxStrategy
+}
+
+func (s *timeoutStrategy) Version(db *sql.DB) (int, error) {
+	return s.VersionContext(context.Background(), db)
+}
+
+func (s *timeoutStrategy) SetVersion(db *sql.DB, version int) error {
+	return s.SetVersionContext(context.Background(), db, version)
+}
+
+func (s *timeoutStrategy) VersionContext(ctx context.Context, db *sql.DB) (int, error) {
+	return withTimeout(ctx, s.readTimeout, func(ctx context.Context) (int, error) {
+		return strategyVersion(ctx, s.inner, db)
+	})
+}
+
+func (s *timeoutStrategy) SetVersionContext(ctx context.Context, db *sql.DB, version int) error {
+	_, err := withTimeout(ctx, s.writeTimeout, func(ctx context.Context) (int, error) {
+		return 0, strategySetVersion(ctx, s.inner, db, version)
+	})
+	return err
+}
+
+func (s *timeoutTxStrategy) VersionTx(tx *sql.Tx) (int, error) {
+	return s.versionTx(context.Background(), tx)
+}
+
+func (s *timeoutTxStrategy) SetVersionTx(tx *sql.Tx, version int) error {
+	return s.setVersionTx(context.Background(), tx, version)
+}
+
+func (s *timeoutTxStrategy) versionTx(ctx context.Context, tx *sql.Tx) (int, error) {
+	return withTimeout(ctx, s.readTimeout, func(ctx context.Context) (int, error) {
+		return strategyVersionTx(ctx, s.txInner, tx)
+	})
+}
+
+func (s *timeoutTxStrategy) setVersionTx(ctx context.Context, tx *sql.Tx, version int) error {
+	_, err := withTimeout(ctx, s.writeTimeout, func(ctx context.Context) (int, error) {
+		return 0, strategySetVersionTx(ctx, s.txInner, tx, version)
+	})
+	return err
+}
+
+type timeoutResult struct {
+	version int
+	err     error
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration, call func(context.Context) (int, error)) (int, error) {
+	if timeout <= 0 {
+		return call(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan timeoutResult, 1)
+	go func() {
+		version, err := call(ctx)
+		done <- timeoutResult{version, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil && ctx.Err() == context.DeadlineExceeded {
+			return 0, fmt.Errorf("%w: %w", ErrOperationTimeout, res.err)
+		}
+		return res.version, res.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return 0, fmt.Errorf("%w: %w", ErrOperationTimeout, ctx.Err())
+		}
+		return 0, ctx.Err()
+	}
+}
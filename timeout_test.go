@@ -0,0 +1,96 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTimeoutStrategyDelegates(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(4, nil).
+		On("SetVersion", db, 5).Return(nil)
+
+	s := NewTimeoutStrategy(strategy, time.Second, time.Second)
+	v, err := s.Version(db)
+	assert.Nil(t, err, "Version must not return error")
+	assert.Equal(t, 4, v, "Inner version must be returned")
+	assert.Nil(t, s.SetVersion(db, 5), "SetVersion must not return error")
+
+	strategy.AssertExpectations(t)
+}
+
+func TestTimeoutStrategyTx(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, ok := NewTimeoutStrategy(strategy, time.Second, time.Second).(TxStrategy)
+	assert.False(t, ok, "TxStrategy is not implemented by the wrapped strategy")
+	s, ok := NewTimeoutStrategy(NewTableStrategy(""), time.Second, time.Second).(TxStrategy)
+	assert.True(t, ok, "TxStrategy of the wrapped strategy must be forwarded")
+
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", true)
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	dbMock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+	v, err := s.VersionTx(tx)
+	assert.Nil(t, err, "VersionTx must not return error")
+	assert.Equal(t, 4, v, "Inner version must be returned")
+	assert.Nil(t, tx.Rollback())
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestTimeoutStrategyAbandonsSlowCall(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(4, nil).
+		Run(func(mock.Arguments) { time.Sleep(200 * time.Millisecond) })
+
+	_, err := NewTimeoutStrategy(strategy, 10*time.Millisecond, 0).Version(db)
+	assert.True(t, errors.Is(err, ErrOperationTimeout), "Timeout must return ErrOperationTimeout")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Timeout must wrap the context error")
+}
+
+func TestTimeoutStrategyForwardsDeadline(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	ctxStrategy := new(contextStrategyMock)
+	ctxStrategy.
+		On("SetVersionContext", mock.Anything, db, 2).Return(context.DeadlineExceeded).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		})
+
+	err := NewTimeoutStrategy(ctxStrategy, 0, 10*time.Millisecond).SetVersion(db, 2)
+	assert.True(t, errors.Is(err, ErrOperationTimeout), "Timeout must return ErrOperationTimeout")
+	ctxStrategy.AssertExpectations(t)
+}
+
+func TestTimeoutStrategyKeepsSchemaErrors(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("SetVersion", db, 2).Return(someError)
+
+	err := NewTimeoutStrategy(strategy, 0, time.Second).SetVersion(db, 2)
+	assert.Equal(t, someError, err, "Inner errors must be passed out unchanged")
+}
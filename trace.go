@@ -0,0 +1,76 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// TracedStatement is a SQL statement recorded by a SQLTrace
+type TracedStatement struct {
+	Query    string
+	Args     []interface{}
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// SQLTrace records the SQL statements executed during a migration. It
+// is safe for concurrent use. The methods of a nil *SQLTrace run the
+// statements without recording them, so callbacks may use the trace
+// returned by SQLTraceFromContext unconditionally
+type SQLTrace struct {
+	mu         sync.Mutex
+	statements []TracedStatement
+}
+
+type sqlTraceKey struct{}
+
+// WithSQLTrace makes the trace available to the scheme callbacks
+// implementing ContextScheme through SQLTraceFromContext. Callbacks
+// must execute their statements through the trace to have them
+// recorded, the SQL schemes such as NewSQLScheme record theirs
+func WithSQLTrace(trace *SQLTrace) Option {
+	return func(o *options) {
+		o.sqlTrace = trace
+	}
+}
+
+// SQLTraceFromContext returns the trace passed by WithSQLTrace to the
+// migration running with ctx, or nil if there is none
+func SQLTraceFromContext(ctx context.Context) *SQLTrace {
+	trace, _ := ctx.Value(sqlTraceKey{}).(*SQLTrace)
+	return trace
+}
+
+// ExecContext executes query on db, recording it along with its
+// arguments, start time, duration and error
+func (t *SQLTrace) ExecContext(ctx context.Context, db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := db.ExecContext(ctx, query, args...)
+	t.Record(TracedStatement{Query: query, Args: args, Start: start, Duration: time.Since(start), Err: err})
+	return res, err
+}
+
+// Record appends stmt to the trace, it is meant for statements not
+// executed by ExecContext
+func (t *SQLTrace) Record(stmt TracedStatement) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.statements = append(t.statements, stmt)
+}
+
+// Statements returns a copy of the recorded statements, in execution
+// order
+func (t *SQLTrace) Statements() []TracedStatement {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TracedStatement(nil), t.statements...)
+}
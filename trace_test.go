@@ -0,0 +1,88 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSQLTraceRecordsCallbackStatements(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	trace := new(SQLTrace)
+	ctxScheme := new(contextSchemeMock)
+
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	ctxScheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake").
+		On("OnCreateContext", mock.Anything, db).Return(nil).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			SQLTraceFromContext(ctx).ExecContext(ctx, args.Get(1).(*sql.DB), "CREATE TABLE users (id int)")
+		})
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, ctxScheme, WithSQLTrace(trace))
+	assert.Nil(t, err, "PersistScheme must not return error on create")
+
+	statements := trace.Statements()
+	if assert.Len(t, statements, 1) {
+		assert.Equal(t, "CREATE TABLE users (id int)", statements[0].Query)
+		assert.Nil(t, statements[0].Err)
+		assert.False(t, statements[0].Start.IsZero(), "Start time must be recorded")
+	}
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSQLTraceNilExecutesWithoutRecording(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	dbMock.ExpectExec("DROP TABLE users").WillReturnError(someError)
+
+	trace := SQLTraceFromContext(context.Background())
+	_, err := trace.ExecContext(context.Background(), db, "DROP TABLE users")
+	assert.Equal(t, someError, err, "Exec error must be passed out")
+	assert.Nil(t, trace.Statements())
+}
+
+func TestSQLTraceRecordsSQLSchemeStatements(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	trace := new(SQLTrace)
+	s := NewSQLScheme(1, "fake", "CREATE TABLE a (id int); CREATE TABLE b (id int)", nil)
+	strategy.
+		On("Version", db).Return(0, nil).
+		On("SetVersion", db, 1).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("CREATE TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec("CREATE TABLE b").WillReturnError(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, s, WithSQLTrace(trace))
+	assert.NotNil(t, err)
+
+	statements := trace.Statements()
+	if assert.Len(t, statements, 2, "Every executed statement must be recorded") {
+		assert.Equal(t, "CREATE TABLE a (id int)", statements[0].Query)
+		assert.Nil(t, statements[0].Err)
+		assert.Equal(t, "CREATE TABLE b (id int)", statements[1].Query)
+		assert.Equal(t, someError, statements[1].Err)
+	}
+}
@@ -0,0 +1,155 @@
+package version
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Errors returned by PersistSchemeTx, to be checked with errors.Is
+var (
+	ErrNilTx          = errors.New("versioned db: tx is nil")
+	ErrTxNotSupported = errors.New("versioned db: strategy or scheme does not support transactions")
+)
+
+// TxStrategy is implemented by strategies able to read and record the
+// version within a transaction of the caller, as PersistSchemeTx
+// requires. The strategy created by NewTableStrategy implements it
+type TxStrategy interface {
+	Strategy
+	VersionTx(tx *sql.Tx) (int, error)
+	SetVersionTx(tx *sql.Tx, version int) error
+}
+
+// TxScheme is implemented by schemes able to migrate within a
+// transaction of the caller, as PersistSchemeTx requires. The schemes
+// returned by LoadSQLScheme, LoadSQLSchemeFromFS and NewSQLScheme
+// implement it
+type TxScheme interface {
+	Scheme
+	OnCreateTx(tx *sql.Tx) error
+	OnUpdateTx(tx *sql.Tx, oldVersion int) error
+}
+
+// contextTxScheme and contextTxStrategy are implemented by the schemes
+// and strategies of the package, so the migration context reaches them
+// when they run within the migration transaction
+type contextTxScheme interface {
+	onCreateTx(ctx context.Context, tx *sql.Tx) error
+	onUpdateTx(ctx context.Context, tx *sql.Tx, oldVersion int) error
+}
+
+type contextTxStrategy interface {
+	versionTx(ctx context.Context, tx *sql.Tx) (int, error)
+	setVersionTx(ctx context.Context, tx *sql.Tx, version int) error
+}
+
+// asTxScheme asserts scheme to TxScheme, unless it is a wrapper around
+// a scheme not implementing it
+func asTxScheme(scheme Scheme) (TxScheme, bool) {
+	s, ok := scheme.(TxScheme)
+	return s, ok && wrappedSchemeSupports(scheme, func(scheme Scheme) bool {
+		_, ok := scheme.(TxScheme)
+		return ok
+	})
+}
+
+func schemeCreateTx(ctx context.Context, scheme TxScheme, tx *sql.Tx) error {
+	if s, ok := scheme.(contextTxScheme); ok {
+		return s.onCreateTx(ctx, tx)
+	}
+	return scheme.OnCreateTx(tx)
+}
+
+func schemeUpdateTx(ctx context.Context, scheme TxScheme, tx *sql.Tx, oldVersion int) error {
+	if s, ok := scheme.(contextTxScheme); ok {
+		return s.onUpdateTx(ctx, tx, oldVersion)
+	}
+	return scheme.OnUpdateTx(tx, oldVersion)
+}
+
+func strategyVersionTx(ctx context.Context, strategy TxStrategy, tx *sql.Tx) (int, error) {
+	if s, ok := strategy.(contextTxStrategy); ok {
+		return s.versionTx(ctx, tx)
+	}
+	return strategy.VersionTx(tx)
+}
+
+func strategySetVersionTx(ctx context.Context, strategy TxStrategy, tx *sql.Tx, version int) error {
+	if s, ok := strategy.(contextTxStrategy); ok {
+		return s.setVersionTx(ctx, tx, version)
+	}
+	return strategy.SetVersionTx(tx, version)
+}
+
+// PersistSchemeTx is like PersistScheme but migrates within tx, so the
+// migration is atomic with the other work of the caller. It never
+// commits nor rolls tx back, that is left to the caller, also when an
+// error is returned. The strategy must implement TxStrategy and, unless
+// the database is up to date, the scheme must implement TxScheme,
+// otherwise ErrTxNotSupported is returned. Databases ahead of the
+// scheme are reported as by PersistScheme with no FuturePolicy set,
+// downgrades are not supported
+func PersistSchemeTx(tx *sql.Tx, scheme Scheme) (MigrationResult, error) {
+	return DefaultRegistry.PersistSchemeTx(tx, scheme)
+}
+
+// PersistSchemeTx is like the package level PersistSchemeTx but
+// resolves the strategy of scheme in r
+func (r *Registry) PersistSchemeTx(tx *sql.Tx, scheme Scheme) (MigrationResult, error) {
+	if tx == nil {
+		return MigrationResult{}, ErrNilTx
+	}
+	strategy, version, err := r.validateScheme(scheme)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+	s, ok := strategy.(TxStrategy)
+	if !ok {
+		return MigrationResult{}, ErrTxNotSupported
+	}
+
+	ctx := withScheme(context.Background(), scheme)
+	dbVersion, err := strategyVersionTx(ctx, s, tx)
+	if err != nil {
+		return MigrationResult{}, err
+	}
+	res := MigrationResult{FromVersion: dbVersion, ToVersion: version, Description: schemeDescription(scheme)}
+	recreate := dbVersion == 0 || schemeRecreates(scheme, dbVersion)
+	if !recreate && dbVersion > version {
+		return MigrationResult{}, fmt.Errorf("%w: %w: database at version %d, scheme at version %d",
+			ErrVersionDowngrade, ErrDatabaseAhead, dbVersion, version)
+	}
+	if !recreate && dbVersion == version {
+		res.Action = ActionNoop
+		return res, nil
+	}
+
+	txScheme, ok := asTxScheme(scheme)
+	if !ok {
+		return MigrationResult{}, ErrTxNotSupported
+	}
+
+	if recreate {
+		res.Action = ActionCreate
+		if err = txScheme.OnCreateTx(tx); err != nil {
+			return MigrationResult{}, fmt.Errorf("%w: %w", ErrMigrationFailed, err)
+		}
+		if err = strategySetVersionTx(withForcedVersion(ctx), s, tx, version); err != nil {
+			return MigrationResult{}, err
+		}
+		return res, nil
+	}
+
+	res.Action = ActionUpdate
+	for step := dbVersion; step < version; step++ {
+		if err = txScheme.OnUpdateTx(tx, step); err != nil {
+			return MigrationResult{}, fmt.Errorf("%w: %w", ErrMigrationFailed, err)
+		}
+		if err = strategySetVersionTx(ctx, s, tx, step+1); err != nil {
+			return MigrationResult{}, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package version
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestPersistSchemeTx(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	s := NewSQLScheme(1, "table", "CREATE TABLE users (id int)", nil)
+
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", false)
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersionTables("schema_version")
+	dbMock.ExpectQuery("SELECT version FROM schema_version").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec("INSERT INTO schema_version \\(version\\)").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("INSERT INTO schema_version_history").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+
+	res, err := PersistSchemeTx(tx, s)
+	assert.Nil(t, err, "PersistSchemeTx must not return error")
+	assert.Equal(t, ActionCreate, res.Action)
+	assert.Nil(t, tx.Commit(), "tx must be left open for the caller")
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistSchemeTxFailureDoesNotCommit(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("table", NewTableStrategy(""))
+	s := NewSQLScheme(1, "table", "CREATE TABLE users (id int)", nil)
+
+	dbMock.ExpectBegin()
+	expectTableLookup("schema_version", false)
+	dbMock.ExpectExec("CREATE TABLE users").WillReturnError(someError)
+
+	tx, err := db.Begin()
+	assert.Nil(t, err)
+
+	_, err = PersistSchemeTx(tx, s)
+	assert.True(t, errors.Is(err, someError), "OnCreate error must be returned")
+	assert.True(t, errors.Is(err, ErrMigrationFailed))
+
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("tx must be neither committed nor rolled back. Err %q", err)
+	}
+
+	dbMock.ExpectRollback()
+	assert.Nil(t, tx.Rollback())
+}
+
+func TestPersistSchemeTxErrors(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	_, err := PersistSchemeTx(nil, scheme)
+	assert.Equal(t, ErrNilTx, err)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	dbMock.ExpectBegin()
+	tx, _ := db.Begin()
+	_, err = PersistSchemeTx(tx, scheme)
+	assert.Equal(t, ErrTxNotSupported, err, "Strategies without TxStrategy must be rejected")
+}
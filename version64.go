@@ -0,0 +1,125 @@
+package version
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Strategy64 is a Strategy recording 64 bit versions, such as
+// timestamps. It is registered by Register64
+type Strategy64 interface {
+	Version(db *sql.DB) (int64, error)
+	SetVersion(db *sql.DB, version int64) error
+}
+
+// Scheme64 is a Scheme with a 64 bit version, persisted by
+// PersistScheme64
+type Scheme64 interface {
+	Version() int64
+	VersionStrategy() string
+	OnCreate(db *sql.DB) error
+	OnUpdate(db *sql.DB, oldVersion int64) error
+}
+
+// Register64 makes a Strategy64 available by the provided name to the
+// schemes persisted by PersistScheme64. It panics like Register does,
+// also when a Strategy is registered with name
+func Register64(name string, strategy Strategy64) {
+	DefaultRegistry.Register64(name, strategy)
+}
+
+// Register64 is like the package level Register64 but registers
+// strategy in r
+func (r *Registry) Register64(name string, strategy Strategy64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if strategy == nil {
+		panic("versioned db: Register64 strategy is nil")
+	}
+	if r.strategies64 == nil {
+		r.strategies64 = make(map[string]Strategy64)
+	}
+	if r.registered(name) {
+		panic("versioned db: Register64 called twice for strategy " + name)
+	}
+	r.strategies64[r.normalize(name)] = strategy
+}
+
+func (r *Registry) lookup64(name string) Strategy64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.strategies64[r.normalize(name)]
+}
+
+// PersistScheme64 creates or updates the database to the version
+// declared by scheme, like PersistScheme does. As versions may be far
+// apart, such as timestamps, OnUpdate is called once with the version
+// the database is at, rather than once per intermediate version. A
+// database ahead of scheme is reported with ErrVersionDowngrade
+func PersistScheme64(db *sql.DB, scheme Scheme64) error {
+	return DefaultRegistry.PersistScheme64(db, scheme)
+}
+
+// PersistScheme64 is like the package level PersistScheme64 but
+// resolves the strategy of scheme in r
+func (r *Registry) PersistScheme64(db *sql.DB, scheme Scheme64) error {
+	var (
+		version  int64
+		strategy Strategy64
+	)
+
+	if db == nil {
+		return ErrNilDB
+	}
+	if scheme == nil {
+		return ErrNilScheme
+	}
+	if version = scheme.Version(); version < 1 {
+		return fmt.Errorf("%w, got %d", ErrVersionBelowOne, version)
+	}
+	if strategy = r.lookup64(scheme.VersionStrategy()); strategy == nil {
+		return fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, scheme.VersionStrategy())
+	}
+
+	dbVersion, err := strategy.Version(db)
+	if err != nil {
+		logf("reading the database version failed: %v", err)
+		return err
+	}
+	logf("using strategy %q, database at version %d, scheme at version %d", scheme.VersionStrategy(), dbVersion, version)
+
+	var run func() error
+	switch {
+	case dbVersion == 0:
+		run = func() error { return scheme.OnCreate(db) }
+	case dbVersion < version:
+		run = func() error { return scheme.OnUpdate(db, dbVersion) }
+	case dbVersion > version:
+		return fmt.Errorf("%w: %w: database at version %d, scheme at version %d",
+			ErrVersionDowngrade, ErrDatabaseAhead, dbVersion, version)
+	default:
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = run(); err != nil {
+		err = fmt.Errorf("%w: %w", ErrMigrationFailed, err)
+	} else {
+		err = strategy.SetVersion(db, version)
+	}
+	if err != nil {
+		tx.Rollback()
+		logf("migration rolled back: %v", err)
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		logf("committing the migration failed: %v", err)
+		return err
+	}
+	logf("migration committed")
+	return nil
+}
@@ -0,0 +1,113 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const timestampVersion int64 = 20240101120000
+
+func TestPersistScheme64Update(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy64, scheme64 := new(strategy64Mock), new(scheme64Mock)
+	Register64("ts", strategy64)
+
+	strategy64.
+		On("Version", db).Return(timestampVersion, nil).
+		On("SetVersion", db, timestampVersion+1).Return(nil)
+	scheme64.
+		On("Version").Return(timestampVersion+1).
+		On("VersionStrategy").Return("ts").
+		On("OnUpdate", db, timestampVersion).Return(nil).Once()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := PersistScheme64(db, scheme64)
+	assert.Nil(t, err, "PersistScheme64 must not return error")
+
+	strategy64.AssertExpectations(t)
+	scheme64.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestPersistScheme64CreateFailure(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy64, scheme64 := new(strategy64Mock), new(scheme64Mock)
+	Register64("ts", strategy64)
+
+	strategy64.On("Version", db).Return(int64(0), nil)
+	scheme64.
+		On("Version").Return(timestampVersion).
+		On("VersionStrategy").Return("ts").
+		On("OnCreate", db).Return(someError)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	err := PersistScheme64(db, scheme64)
+	assert.True(t, errors.Is(err, ErrMigrationFailed), "OnCreate error must be wrapped")
+	strategy64.AssertNotCalled(t, "SetVersion", db, timestampVersion)
+}
+
+func TestPersistScheme64Errors(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	scheme64 := new(scheme64Mock)
+	assert.Equal(t, ErrNilDB, PersistScheme64(nil, scheme64))
+	assert.Equal(t, ErrNilScheme, PersistScheme64(db, nil))
+
+	scheme64.
+		On("Version").Return(timestampVersion).
+		On("VersionStrategy").Return("fake")
+	err := PersistScheme64(db, scheme64)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Strategies registered by Register must not be used")
+}
+
+// Stubs
+/////////////////////////////////////////////////////
+
+type strategy64Mock struct {
+	mock.Mock
+}
+
+func (s *strategy64Mock) Version(db *sql.DB) (int64, error) {
+	args := s.Called(db)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *strategy64Mock) SetVersion(db *sql.DB, version int64) error {
+	return s.Called(db, version).Error(0)
+}
+
+type scheme64Mock struct {
+	mock.Mock
+}
+
+func (s *scheme64Mock) Version() int64 {
+	return s.Called().Get(0).(int64)
+}
+
+func (s *scheme64Mock) VersionStrategy() string {
+	return s.Called().String(0)
+}
+
+func (s *scheme64Mock) OnCreate(db *sql.DB) error {
+	return s.Called(db).Error(0)
+}
+
+func (s *scheme64Mock) OnUpdate(db *sql.DB, oldVersion int64) error {
+	return s.Called(db, oldVersion).Error(0)
+}
@@ -1,10 +1,12 @@
 package version
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"sync"
+	"strconv"
+	"time"
 )
 
 type Strategy interface {
@@ -12,16 +14,51 @@ type Strategy interface {
 	SetVersion(db *sql.DB, version int) error
 }
 
+// SchemeVersion is the version a Scheme migrates the database to
+type SchemeVersion int
+
+// IsValid reports whether v is a usable scheme version, that is, at
+// least 1
+func (v SchemeVersion) IsValid() bool {
+	return v >= 1
+}
+
+// Int returns v as the plain int strategies store
+func (v SchemeVersion) Int() int {
+	return int(v)
+}
+
+func (v SchemeVersion) String() string {
+	return strconv.Itoa(int(v))
+}
+
 type Scheme interface {
-	Version() int
+	Version() SchemeVersion
 	VersionStrategy() string
 	OnCreate(db *sql.DB) error
 	OnUpdate(db *sql.DB, oldVersion int) error
 }
 
+// ErrVersionAlreadyRegistered is returned by RegisterOrError when a
+// strategy is already registered with the same name
+var ErrVersionAlreadyRegistered = errors.New("versioned db: strategy already registered")
+
+// ErrStrategyNotFound is returned by Unregister when no strategy is
+// registered with the given name
+var ErrStrategyNotFound = errors.New("versioned db: strategy not registered")
+
+// Errors returned, or wrapped, by PersistScheme and the other functions
+// of the package, to be checked with errors.Is
 var (
-	versionDriversMu sync.RWMutex
-	versionDrivers   = make(map[string]Strategy)
+	ErrNilDB           = errors.New("versioned db: db is nil")
+	ErrNilScheme       = errors.New("versioned db: scheme is nil")
+	ErrVersionBelowOne = errors.New("versioned db: version is less then one")
+	ErrUnknownStrategy = errors.New("versioned db: unknown strategy")
+	ErrNilStrategy     = errors.New("versioned db: strategy is nil")
+	ErrNegativeVersion = errors.New("versioned db: version is negative")
+	// ErrMigrationFailed wraps the errors returned by the OnCreate,
+	// OnUpdate and OnDowngrade callbacks of a scheme
+	ErrMigrationFailed = errors.New("versioned db: migration failed")
 )
 
 // Register makes a scheme available for a versioned
@@ -29,89 +66,456 @@ var (
 // It panics if the passed scheme is nil or if a scheme already is
 // registered with the same name
 func Register(name string, strategy Strategy) {
-	versionDriversMu.Lock()
-	defer versionDriversMu.Unlock()
+	DefaultRegistry.Register(name, strategy)
+}
+
+// RegisterOrError makes a strategy available by the provided name
+// like Register does, but returns ErrVersionAlreadyRegistered instead
+// of panicking if a strategy already is registered with the same name.
+// It returns an error if the passed strategy is nil
+func RegisterOrError(name string, strategy Strategy) error {
+	return DefaultRegistry.RegisterOrError(name, strategy)
+}
+
+// Unregister removes the Strategy or Strategy64 registered by name, so
+// the name can be registered again. It returns ErrStrategyNotFound if
+// no strategy is registered with that name
+func Unregister(name string) error {
+	return DefaultRegistry.Unregister(name)
+}
+
+// Strategies returns the sorted names of the registered strategies,
+// those registered by Register64 included
+func Strategies() []string {
+	return DefaultRegistry.Strategies()
+}
+
+// PersistScheme creates or updates the database to the version
+// declared by scheme, reporting which migration ran. Updates run one
+// step per version. The steps not run within the migration transaction
+// record their version as they complete, so an interrupted migration
+// resumes from the step that did not complete on the next call. The
+// steps within the transaction are undone together by its rollback
+func PersistScheme(db *sql.DB, scheme Scheme, opts ...Option) (MigrationResult, error) {
+	return DefaultRegistry.PersistScheme(db, scheme, opts...)
+}
+
+// PersistSchemeOptions is like PersistScheme but only reports whether
+// the migration failed, for callers not needing the MigrationResult
+func PersistSchemeOptions(db *sql.DB, scheme Scheme, opts ...Option) error {
+	_, err := PersistScheme(db, scheme, opts...)
+	return err
+}
 
-	if strategy == nil {
-		panic("versioned db: Register strategy is nil")
+// MustPersistScheme is like PersistScheme but panics if the migration
+// fails, the panic value being the returned error. It is meant for
+// program startups treating a failed migration as unrecoverable
+func MustPersistScheme(db *sql.DB, scheme Scheme, opts ...Option) MigrationResult {
+	res, err := PersistScheme(db, scheme, opts...)
+	if err != nil {
+		panic(err)
 	}
-	if _, dup := versionDrivers[name]; dup {
-		panic("versioned db: Register called twice for strategy " + name)
+	return res
+}
+
+// PersistSchemeContext is like PersistScheme but forwards ctx to the
+// migration transaction and to the strategy and scheme callbacks that
+// implement ContextStrategy and ContextScheme
+func PersistSchemeContext(ctx context.Context, db *sql.DB, scheme Scheme, opts ...Option) (MigrationResult, error) {
+	return DefaultRegistry.PersistSchemeContext(ctx, db, scheme, opts...)
+}
+
+// ValidateScheme checks that scheme can be persisted, without
+// accessing any database: it must not be nil, its version must be at
+// least one and its strategy must be registered. The returned error
+// wraps ErrNilScheme, ErrVersionBelowOne or ErrUnknownStrategy
+func ValidateScheme(scheme Scheme) error {
+	return DefaultRegistry.ValidateScheme(scheme)
+}
+
+// ForceVersion records version as the current version of the
+// strategy registered by strategyName without running any migration.
+// It is meant for recovery and test setups, PersistScheme must be
+// used to migrate a database
+func ForceVersion(db *sql.DB, strategyName string, version int) error {
+	return DefaultRegistry.ForceVersion(db, strategyName, version)
+}
+
+// ForceSetVersion is ForceVersion, named for operators advancing the
+// recorded version after a schema change applied out of band. No
+// migration callback runs, any non-negative version is accepted, even
+// below the recorded one, and a nil db or an unknown strategy is
+// rejected with ErrNilDB or ErrUnknownStrategy
+func ForceSetVersion(db *sql.DB, strategyName string, version int) error {
+	return ForceVersion(db, strategyName, version)
+}
+
+// PersistScheme is like the package level PersistScheme but
+// resolves the strategy of scheme in r
+func (r *Registry) PersistScheme(db *sql.DB, scheme Scheme, opts ...Option) (MigrationResult, error) {
+	return r.PersistSchemeContext(context.Background(), db, scheme, opts...)
+}
+
+// PersistSchemeContext is like the package level PersistSchemeContext
+// but resolves the strategy of scheme in r
+func (r *Registry) PersistSchemeContext(ctx context.Context, db *sql.DB, scheme Scheme, opts ...Option) (MigrationResult, error) {
+	strategy, version, err := r.resolveScheme(db, scheme)
+	if err != nil {
+		return MigrationResult{}, err
 	}
-	versionDrivers[name] = strategy
+	logf("using strategy %q for scheme version %d", scheme.VersionStrategy(), version)
+	return persistSchemeInternal(ctx, strategy, db, version, scheme, newOptions(opts))
+}
+
+// ValidateScheme is like the package level ValidateScheme but looks
+// the strategy of scheme up in r
+func (r *Registry) ValidateScheme(scheme Scheme) error {
+	_, _, err := r.validateScheme(scheme)
+	return err
 }
 
-func PersistScheme(db *sql.DB, scheme Scheme) error {
+// resolveScheme validates db and scheme, returning the strategy and
+// version of scheme
+func (r *Registry) resolveScheme(db *sql.DB, scheme Scheme) (Strategy, int, error) {
+	if db == nil {
+		return nil, 0, ErrNilDB
+	}
+	return r.validateScheme(scheme)
+}
+
+func (r *Registry) validateScheme(scheme Scheme) (Strategy, int, error) {
 	var (
 		version  int
 		strategy Strategy
 	)
 
+	if scheme == nil {
+		return nil, 0, ErrNilScheme
+	}
+
+	if v := scheme.Version(); !v.IsValid() {
+		return nil, 0, fmt.Errorf("%w, got %d", ErrVersionBelowOne, v)
+	} else {
+		version = v.Int()
+	}
+
+	if strategy = r.lookup(scheme.VersionStrategy()); strategy == nil {
+		return nil, 0, fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, scheme.VersionStrategy())
+	}
+
+	return strategy, version, nil
+}
+
+// ForceVersion is like the package level ForceVersion but looks
+// strategyName up in r
+func (r *Registry) ForceVersion(db *sql.DB, strategyName string, version int) error {
+	var strategy Strategy
+
 	if db == nil {
-		return errors.New("versioned db: db is nil")
+		return ErrNilDB
 	}
 
-	if scheme == nil {
-		return errors.New("versioned db: scheme is nil")
+	if version < 0 {
+		return ErrNegativeVersion
 	}
 
-	if version = scheme.Version(); version < 1 {
-		return errors.New("versioned db: version is less then one")
+	if strategy = r.lookup(strategyName); strategy == nil {
+		return fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, strategyName)
 	}
 
-	if strategy = strategyFromString(scheme.VersionStrategy()); strategy == nil {
-		return fmt.Errorf("versioned db: unknown v scheme %q (forgotten import?)", scheme.VersionStrategy())
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
 
-	return persistSchemeInternal(strategy, db, version, scheme)
+	if err = strategySetVersion(withForcedVersion(context.Background()), strategy, db, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
-func strategyFromString(name string) Strategy {
-	versionDriversMu.RLock()
-	versionDriver, ok := versionDrivers[name]
-	versionDriversMu.RUnlock()
-	if ok {
-		return versionDriver
+// migrationStep moves a database from one version to the next version
+// recorded by strategy, to. runTx, set for schemes implementing
+// TxScheme, runs the step within the migration transaction instead.
+// forced steps, creations and downgrades, record to even where it does
+// not follow from
+type migrationStep struct {
+	strategy Strategy
+	scheme   Scheme
+	from, to int
+	run      func(*sql.DB) error
+	runTx    func(*sql.Tx) error
+	forced   bool
+}
+
+// inTx tells whether the step and its version record run within the
+// migration transaction, which requires a TxScheme and a TxStrategy
+func (s migrationStep) inTx() bool {
+	_, ok := s.strategy.(TxStrategy)
+	return ok && s.runTx != nil
+}
+
+func (s migrationStep) migrate(db *sql.DB, tx *sql.Tx) error {
+	if s.inTx() {
+		return s.runTx(tx)
 	}
-	return nil
+	return s.run(db)
 }
 
-func persistSchemeInternal(strategy Strategy, db *sql.DB, version int, scheme Scheme) error {
-	var createOrUpdate func(*sql.DB) error
+func (s migrationStep) setVersion(ctx context.Context, db *sql.DB, tx *sql.Tx) error {
+	if s.forced {
+		ctx = withForcedVersion(ctx)
+	}
+	if s.inTx() {
+		return strategySetVersionTx(ctx, s.strategy.(TxStrategy), tx, s.to)
+	}
+	return strategySetVersion(ctx, s.strategy, db, s.to)
+}
 
-	tx, err := db.Begin()
+// migrationPlan holds the steps migrating a database to a scheme, none
+// when the database is left untouched
+type migrationPlan struct {
+	result MigrationResult
+	steps  []migrationStep
+}
+
+func persistSchemeInternal(ctx context.Context, strategy Strategy, db *sql.DB, version int, scheme Scheme, o *options) (MigrationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return MigrationResult{}, err
+	}
+
+	dbCtx, cancel := o.migrationContext(ctx)
+	defer cancel()
+
+	plan, err := planMigration(dbCtx, strategy, db, version, scheme, o)
 	if err != nil {
-		return err
+		return MigrationResult{}, err
+	}
+	if plan.result.Description != "" {
+		logf("scheme %q", plan.result.Description)
+	}
+	logf("database at version %d, %s to version %d", plan.result.FromVersion, plan.result.Action, plan.result.ToVersion)
+	if plan.steps == nil {
+		return plan.result, nil
 	}
 
-	dbVersion, err := strategy.Version(db)
+	event := o.startEvent(scheme, plan.result)
+	obs, name, action := currentObserver(), scheme.VersionStrategy(), plan.result.Action.String()
+	if obs != nil {
+		obs.OnMigrationStart(dbCtx, name, action, plan.result.FromVersion, plan.result.ToVersion)
+	}
+	err = applySteps(ctx, dbCtx, db, plan.steps, o, migrationHooks{
+		replan: func(tx *sql.Tx) ([]migrationStep, error) {
+			if plan, err = replanInTx(dbCtx, tx, strategy, version, scheme, plan, o); err != nil {
+				return nil, err
+			}
+			event.Action, event.OldVersion, event.NewVersion = plan.result.Action, plan.result.FromVersion, plan.result.ToVersion
+			return plan.steps, nil
+		},
+		beforeCommit: func(tx *sql.Tx) error {
+			return o.emitTx(dbCtx, tx, event)
+		},
+	})
+	o.finishEvent(event, err)
+	if obs != nil {
+		obs.OnMigrationEnd(dbCtx, name, action, plan.result.FromVersion, plan.result.ToVersion, time.Since(event.Timestamp), err)
+	}
 	if err != nil {
-		goto rollback
+		return MigrationResult{}, err
+	}
+	return plan.result, nil
+}
+
+// planMigration reads the version of db to plan its migration to
+// scheme. The version is read before opening the transaction so up to
+// date databases are left untouched
+func planMigration(dbCtx context.Context, strategy Strategy, db *sql.DB, version int, scheme Scheme, o *options) (*migrationPlan, error) {
+	dbVersion, err := o.readVersion(withScheme(dbCtx, scheme), strategy, db)
+	if err != nil {
+		logf("reading the database version failed: %v", err)
+		return nil, err
+	}
+	return planSteps(dbCtx, strategy, dbVersion, version, scheme, o)
+}
+
+// replanInTx reads the version of plan again within tx, for strategies
+// implementing TxStrategy, whose VersionTx may lock the version until
+// tx ends. A concurrent migration may have moved the database since
+// plan was made, it is then planned again from the version read
+func replanInTx(dbCtx context.Context, tx *sql.Tx, strategy Strategy, version int, scheme Scheme, plan *migrationPlan, o *options) (*migrationPlan, error) {
+	s, ok := strategy.(TxStrategy)
+	if !ok {
+		return plan, nil
+	}
+	dbVersion, err := strategyVersionTx(withScheme(dbCtx, scheme), s, tx)
+	if err != nil {
+		logf("reading the database version failed: %v", err)
+		return nil, err
+	}
+	if dbVersion == plan.result.FromVersion {
+		return plan, nil
+	}
+	logf("database moved to version %d, planning again", dbVersion)
+	return planSteps(dbCtx, strategy, dbVersion, version, scheme, o)
+}
+
+// planSteps plans the migration to scheme of a database at dbVersion
+func planSteps(dbCtx context.Context, strategy Strategy, dbVersion, version int, scheme Scheme, o *options) (*migrationPlan, error) {
+	dbCtx = withScheme(dbCtx, scheme)
+
+	// Updates stop at the target version when one is set, creations
+	// always build the scheme at its own version
+	target := version
+	if o.targetVersion > 0 {
+		target = o.targetVersion
+	}
+	if o.singleStep && target > dbVersion+1 {
+		target = dbVersion + 1
 	}
 
-	if dbVersion == 0 {
-		createOrUpdate = scheme.OnCreate
-		goto finalize
-	} else if dbVersion < version {
-		createOrUpdate = func(db *sql.DB) error { return scheme.OnUpdate(db, dbVersion) }
-		goto finalize
+	plan := &migrationPlan{result: MigrationResult{FromVersion: dbVersion, ToVersion: version}}
+	if dbVersion == 0 || schemeRecreates(scheme, dbVersion) {
+		plan.result.Action = ActionCreate
+		step := migrationStep{strategy: strategy, scheme: scheme, from: dbVersion, to: version, forced: true, run: func(db *sql.DB) error {
+			return schemeCreate(dbCtx, scheme, db)
+		}}
+		if s, ok := asTxScheme(scheme); ok {
+			step.runTx = func(tx *sql.Tx) error {
+				return schemeCreateTx(dbCtx, s, tx)
+			}
+		}
+		plan.steps = []migrationStep{step}
+	} else if dbVersion < target {
+		plan.result.Action = ActionUpdate
+		plan.result.ToVersion = target
+		// Every intermediate version is updated to in order, a database
+		// lagging several versions behind must not skip any of them
+		for step := dbVersion; step < target; step++ {
+			step := step
+			update := migrationStep{strategy: strategy, scheme: scheme, from: step, to: step + 1, run: func(db *sql.DB) error {
+				return schemeUpdate(dbCtx, scheme, db, step)
+			}}
+			if s, ok := asTxScheme(scheme); ok {
+				update.runTx = func(tx *sql.Tx) error {
+					return schemeUpdateTx(dbCtx, s, tx, step)
+				}
+			}
+			plan.steps = append(plan.steps, update)
+		}
+	} else if dbVersion > version && o.futurePolicy == FuturePolicyError {
+		return nil, fmt.Errorf("%w: database at version %d, scheme at version %d", ErrDatabaseAhead, dbVersion, version)
+	} else if dbVersion > version && o.futurePolicy == futurePolicyDefault && !o.downgrades(scheme) {
+		return nil, fmt.Errorf("%w: %w: database at version %d, scheme at version %d", ErrVersionDowngrade, ErrDatabaseAhead, dbVersion, version)
+	} else if dbVersion > version && o.downgrades(scheme) {
+		s, ok := asDowngradeScheme(scheme)
+		if !ok {
+			return nil, ErrDowngradeNotSupported
+		}
+		plan.result.Action = ActionDowngrade
+		plan.steps = []migrationStep{{strategy: strategy, scheme: scheme, from: dbVersion, to: version, forced: true, run: func(db *sql.DB) error {
+			return s.OnDowngrade(db, version)
+		}}}
+	} else {
+		plan.result = MigrationResult{Action: ActionNoop, FromVersion: dbVersion, ToVersion: dbVersion}
 	}
+	plan.result.Description = schemeDescription(scheme)
+	return plan, nil
+}
 
-	goto rollback
+// migrationHooks run within the transaction of applySteps. replan,
+// called once the transaction is opened, returns the steps to run
+// instead of those given. beforeCommit runs once every step succeeded
+type migrationHooks struct {
+	replan       func(tx *sql.Tx) ([]migrationStep, error)
+	beforeCommit func(tx *sql.Tx) error
+}
 
-finalize:
-	err = createOrUpdate(db)
+// applySteps runs steps in a single transaction, committing it unless
+// the migration is a dry run. Steps supporting it run within the
+// transaction, the others on db. When o.txOnly is set every step must
+// support it, none is run otherwise. The cancellation of ctx is checked
+// between steps. Under WithAutoRollback the steps done on db are undone
+// when the migration fails
+func applySteps(ctx, dbCtx context.Context, db *sql.DB, steps []migrationStep, o *options, hooks migrationHooks) error {
+	if err := o.checkTxOnly(steps); err != nil {
+		return err
+	}
+
+	var done []migrationStep
+
+	tx, err := db.BeginTx(dbCtx, nil)
 	if err != nil {
+		return err
+	}
+
+	if hooks.replan != nil {
+		if steps, err = hooks.replan(tx); err != nil {
+			goto rollback
+		}
+		if err = o.checkTxOnly(steps); err != nil {
+			goto rollback
+		}
+	}
+	for _, step := range steps {
+		if err = ctx.Err(); err != nil {
+			goto rollback
+		}
+		err = step.migrate(db, tx)
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrMigrationFailed, err)
+			goto rollback
+		}
+		if err = ctx.Err(); err != nil {
+			goto rollback
+		}
+		if o.transitionHook != nil {
+			err = o.transitionHook(db, step.from, step.to)
+			if err != nil {
+				goto rollback
+			}
+		}
+		err = step.setVersion(withScheme(dbCtx, step.scheme), db, tx)
+		if err != nil {
+			goto rollback
+		}
+		done = append(done, step)
+	}
+	if err = ctx.Err(); err != nil {
 		goto rollback
 	}
-	err = strategy.SetVersion(db, version)
-	if err != nil {
+	if hooks.beforeCommit != nil {
+		if err = hooks.beforeCommit(tx); err != nil {
+			goto rollback
+		}
+	}
+	if o.info.IsDryRun {
 		goto rollback
 	}
-	return tx.Commit()
+	if err = tx.Commit(); err != nil {
+		logf("committing the migration failed: %v", err)
+		return err
+	}
+	logf("migration committed")
+	return nil
 
 rollback:
 	tx.Rollback()
-	return err
+	logf("migration rolled back: %v", err)
+	return o.rollbackSteps(dbCtx, db, done, err)
+
+}
 
+// checkTxOnly returns ErrTxNotSupported when o.txOnly is set and a step
+// cannot run within the migration transaction
+func (o *options) checkTxOnly(steps []migrationStep) error {
+	if !o.txOnly {
+		return nil
+	}
+	for _, step := range steps {
+		if !step.inTx() {
+			return ErrTxNotSupported
+		}
+	}
+	return nil
 }
@@ -1,5 +1,7 @@
 package version
 
+//go:generate go run ./cmd/mockgen-strategy -name mock -o strategy_mock_test.go
+
 import "testing"
 import (
 	"database/sql"
@@ -27,7 +29,7 @@ func setup(t *testing.T) {
 }
 
 func tearsDown(*testing.T) {
-	versionDrivers = make(map[string]Strategy)
+	DefaultRegistry = NewRegistry()
 	db.Close()
 }
 
@@ -35,7 +37,7 @@ func TestRegister(t *testing.T) {
 	setup(t)
 	defer tearsDown(t)
 
-	registeredDriver, _ := versionDrivers["fake"]
+	registeredDriver, _ := DefaultRegistry.strategies["fake"]
 	assert.Equal(t, strategy, registeredDriver, "Driver was not registered")
 }
 
@@ -62,6 +64,64 @@ func TestRegisterNilStrategy(t *testing.T) {
 	Register("fake", nil)
 }
 
+func TestRegisterOrError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	other := new(versionStrategyMock)
+	err := RegisterOrError("other", other)
+	assert.Nil(t, err, "Registering a new name must not return error")
+	assert.Equal(t, other, DefaultRegistry.strategies["other"], "Driver was not registered")
+}
+
+func TestRegisterOrErrorDuplicated(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	err := RegisterOrError("fake", new(versionStrategyMock))
+	assert.Equal(t, ErrVersionAlreadyRegistered, err, "Duplicate registering must return ErrVersionAlreadyRegistered")
+	assert.Equal(t, strategy, DefaultRegistry.strategies["fake"], "Registered driver must not be replaced")
+}
+
+func TestRegisterOrErrorNilStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	err := RegisterOrError("other", nil)
+	assert.True(t, errors.Is(err, ErrNilStrategy), "Registering nil must return ErrNilStrategy")
+	_, registered := DefaultRegistry.strategies["other"]
+	assert.False(t, registered, "Nil strategy must not be registered")
+}
+
+func TestUnregister(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	err := Unregister("fake")
+	assert.Nil(t, err, "Unregistering a registered name must not return error")
+	_, registered := DefaultRegistry.strategies["fake"]
+	assert.False(t, registered, "Strategy must be removed")
+	assert.Nil(t, RegisterOrError("fake", strategy), "Unregistered name must be registrable again")
+}
+
+func TestUnregisterNotFound(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	err := Unregister("other")
+	assert.Equal(t, ErrStrategyNotFound, err, "Unknown name must return ErrStrategyNotFound")
+}
+
+func TestStrategies(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	Register("alpha", new(versionStrategyMock))
+	Register("zeta", new(versionStrategyMock))
+
+	assert.Equal(t, []string{"alpha", "fake", "zeta"}, Strategies(), "Names must be sorted")
+}
+
 func TestSchemeCreation(t *testing.T) {
 	setup(t)
 	defer tearsDown(t)
@@ -79,8 +139,9 @@ func TestSchemeCreation(t *testing.T) {
 		On("OnCreate", db).Return(nil)
 	dbMock.ExpectCommit()
 
-	err := PersistScheme(db, scheme)
+	res, err := PersistScheme(db, scheme)
 	assert.Nil(t, err, "PersistScheme must not return error on create")
+	assert.Equal(t, MigrationResult{Action: ActionCreate, FromVersion: 0, ToVersion: dbVersion}, res)
 
 	strategy.AssertExpectations(t)
 	scheme.AssertExpectations(t)
@@ -106,8 +167,9 @@ func TestSchemeCreationError(t *testing.T) {
 		On("OnCreate", db).Return(someError)
 	dbMock.ExpectRollback()
 
-	err := PersistScheme(db, scheme)
-	assert.NotNil(t, err, "Creation error not passed out")
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, ErrMigrationFailed), "Creation error must be reported as a failed migration")
+	assert.True(t, errors.Is(err, someError), "Creation error not passed out")
 
 	strategy.AssertExpectations(t)
 	scheme.AssertExpectations(t)
@@ -134,8 +196,8 @@ func TestVersionError(t *testing.T) {
 		On("OnCreate", db).Return(nil)
 	dbMock.ExpectRollback()
 
-	err := PersistScheme(db, scheme)
-	assert.NotNil(t, err, "Version error not passed out")
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, someError), "Version error not passed out")
 
 	strategy.AssertExpectations(t)
 	scheme.AssertExpectations(t)
@@ -162,13 +224,114 @@ func TestSchemeUpdate(t *testing.T) {
 		On("OnUpdate", db, dbVersion-1).Return(nil)
 	dbMock.ExpectCommit()
 
-	err := PersistScheme(db, scheme)
+	_, err := PersistScheme(db, scheme)
 	assert.Nil(t, err, "PersistScheme must not return error on create")
 
 	strategy.AssertExpectations(t)
 	scheme.AssertExpectations(t)
 }
 
+func TestSchemeUpdateRunsEveryStep(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	var steps []int
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil).
+		On("SetVersion", db, 3).Return(nil).
+		On("SetVersion", db, 4).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) { steps = append(steps, args.Int(1)) })
+	dbMock.ExpectCommit()
+
+	res, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error on update")
+	assert.Equal(t, []int{1, 2, 3}, steps, "Every intermediate step must be updated in order")
+	assert.Equal(t, MigrationResult{Action: ActionUpdate, FromVersion: 1, ToVersion: 4}, res)
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSchemeUpdateStepErrorRollsBack(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).
+		On("SetVersion", db, 2).Return(nil)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).
+		On("OnUpdate", db, 2).Return(someError)
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, ErrMigrationFailed), "Step error must be wrapped")
+	assert.True(t, errors.Is(err, someError), "Step error must be passed out")
+
+	scheme.AssertNotCalled(t, "OnUpdate", db, 3)
+	strategy.AssertNotCalled(t, "SetVersion", db, 3)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSchemeUpdateResumesInterruptedMigration(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(1, nil).Once().
+		On("SetVersion", db, 2).Return(nil).Once().
+		On("SetVersion", db, 3).Return(nil).Once()
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(4).
+		On("VersionStrategy").Return("fake").
+		On("OnUpdate", db, 1).Return(nil).Once().
+		On("OnUpdate", db, 2).Return(nil).Once().
+		On("OnUpdate", db, 3).Return(someError).Once()
+	dbMock.ExpectRollback()
+
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, someError), "Step error must be passed out")
+
+	strategy.
+		On("Version", db).Return(3, nil).Once().
+		On("SetVersion", db, 4).Return(nil).Once()
+	scheme.On("OnUpdate", db, 3).Return(nil).Once()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	res, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error when resuming")
+	assert.Equal(t, MigrationResult{Action: ActionUpdate, FromVersion: 3, ToVersion: 4}, res)
+
+	strategy.AssertExpectations(t)
+	scheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
 func TestSchemeUpdateError(t *testing.T) {
 	setup(t)
 	defer tearsDown(t)
@@ -185,8 +348,9 @@ func TestSchemeUpdateError(t *testing.T) {
 		On("OnUpdate", db, dbVersion-1).Return(someError)
 	dbMock.ExpectRollback()
 
-	err := PersistScheme(db, scheme)
-	assert.NotNil(t, err, "Update error must be passed out")
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, ErrMigrationFailed), "Update error must be reported as a failed migration")
+	assert.True(t, errors.Is(err, someError), "Update error must be passed out")
 
 	strategy.AssertExpectations(t)
 	scheme.AssertExpectations(t)
@@ -205,27 +369,66 @@ func TestSchemeUpToDate(t *testing.T) {
 		On("Version").Return(dbVersion).
 		On("VersionStrategy").Return("fake")
 
-	dbMock.ExpectBegin()
-	err := PersistScheme(db, scheme)
+	res, err := PersistScheme(db, scheme)
 	assert.Nil(t, err, "Up to date database does not return error")
+	assert.Equal(t, ActionNoop, res.Action, "Up to date database must report a noop")
+	assert.Equal(t, MigrationResult{}.Action, res.Action, "Zero result must be a noop")
 
-	dbMock.ExpectRollback()
 	strategy.AssertExpectations(t)
 	scheme.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Up to date database must not open a transaction. Err %q", err)
+	}
+}
+
+func TestSchemeVersionReadErrorSkipsTransaction(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.
+		On("Version", db).Return(0, someError)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	_, err := PersistScheme(db, scheme)
+	assert.Equal(t, someError, err, "Version read error must be passed out")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Failed version read must not open a transaction. Err %q", err)
+	}
 }
 
 func TestPersistSchemeOnNilDb(t *testing.T) {
 	setup(t)
 	defer tearsDown(t)
-	err := PersistScheme(nil, scheme)
-	assert.NotNil(t, err, "An error must be returned when db is nil")
+	_, err := PersistScheme(nil, scheme)
+	assert.True(t, errors.Is(err, ErrNilDB), "ErrNilDB must be returned when db is nil")
+}
+
+func TestMustPersistScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("Version", db).Return(1, nil)
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	assert.Equal(t, ActionNoop, MustPersistScheme(db, scheme).Action)
+	assert.PanicsWithValue(t, ErrNilDB, func() { MustPersistScheme(nil, scheme) },
+		"The panic value must be the returned error")
 }
 
 func TestPersistNilScheme(t *testing.T) {
 	setup(t)
 	defer tearsDown(t)
-	err := PersistScheme(db, nil)
-	assert.NotNil(t, err, "An error must be returned when scheme is nil")
+	_, err := PersistScheme(db, nil)
+	assert.True(t, errors.Is(err, ErrNilScheme), "ErrNilScheme must be returned when scheme is nil")
 	scheme.AssertExpectations(t)
 }
 
@@ -233,8 +436,16 @@ func TestNegativeVersion(t *testing.T) {
 	setup(t)
 	defer tearsDown(t)
 	scheme.On("Version").Return(-1)
-	err := PersistScheme(db, scheme)
-	assert.NotNil(t, err, "It must return err if a negative version is provided")
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, ErrVersionBelowOne), "ErrVersionBelowOne must be returned for a negative version")
+}
+
+func TestSchemeVersion(t *testing.T) {
+	assert.True(t, SchemeVersion(1).IsValid())
+	assert.False(t, SchemeVersion(0).IsValid(), "Version zero must not be valid")
+	assert.False(t, SchemeVersion(-1).IsValid(), "Negative versions must not be valid")
+	assert.Equal(t, 7, SchemeVersion(7).Int())
+	assert.Equal(t, "7", SchemeVersion(7).String())
 }
 
 func TestPersistSchemeUsingUnregisteredStrategy(t *testing.T) {
@@ -243,8 +454,130 @@ func TestPersistSchemeUsingUnregisteredStrategy(t *testing.T) {
 	scheme.
 		On("VersionStrategy").Return("not_registered").
 		On("Version").Return(1)
-	err := PersistScheme(db, scheme)
-	assert.NotNil(t, err, "An error must be returned when a scheme is not registered")
+	_, err := PersistScheme(db, scheme)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "ErrUnknownStrategy must be returned when a strategy is not registered")
+	scheme.AssertExpectations(t)
+}
+
+func TestValidateScheme(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("fake")
+
+	assert.Nil(t, ValidateScheme(scheme), "Valid scheme must not return error")
+	scheme.AssertExpectations(t)
+}
+
+func TestValidateSchemeErrors(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	assert.True(t, errors.Is(ValidateScheme(nil), ErrNilScheme), "Nil scheme must return ErrNilScheme")
+
+	belowOne := new(schemeMock)
+	belowOne.On("Version").Return(0)
+	assert.True(t, errors.Is(ValidateScheme(belowOne), ErrVersionBelowOne), "Version zero must return ErrVersionBelowOne")
+
+	unknown := new(schemeMock)
+	unknown.
+		On("Version").Return(1).
+		On("VersionStrategy").Return("not_registered")
+	err := ValidateScheme(unknown)
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "Unregistered strategy must return ErrUnknownStrategy")
+	assert.Contains(t, err.Error(), "not_registered", "The strategy name must be reported")
+}
+
+func TestForceVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("SetVersion", db, 0).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := ForceVersion(db, "fake", 0)
+	assert.Nil(t, err, "ForceVersion must not return error")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestForceVersionError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("SetVersion", db, 3).Return(someError)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	err := ForceVersion(db, "fake", 3)
+	assert.Equal(t, someError, err, "SetVersion error not passed out")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestForceVersionInvalidInput(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	assert.True(t, errors.Is(ForceVersion(nil, "fake", 1), ErrNilDB), "ErrNilDB must be returned when db is nil")
+	assert.True(t, errors.Is(ForceVersion(db, "fake", -1), ErrNegativeVersion), "ErrNegativeVersion must be returned for negative versions")
+	assert.True(t, errors.Is(ForceVersion(db, "not_registered", 1), ErrUnknownStrategy), "ErrUnknownStrategy must be returned for unknown strategies")
+}
+
+func TestForceSetVersion(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	strategy.On("SetVersion", db, 5).Return(nil)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	err := ForceSetVersion(db, "fake", 5)
+	assert.Nil(t, err, "ForceSetVersion must not return error")
+	assert.True(t, errors.Is(ForceSetVersion(nil, "fake", 1), ErrNilDB), "ErrNilDB must be returned when db is nil")
+	assert.True(t, errors.Is(ForceSetVersion(db, "not_registered", 1), ErrUnknownStrategy), "ErrUnknownStrategy must be returned for unknown strategies")
+
+	strategy.AssertExpectations(t)
+	err = dbMock.ExpectationsWereMet()
+	if err != nil {
+		t.Errorf("Expectations not met. Err %q", err)
+	}
+}
+
+func TestSchemeCreationWithGeneratedMock(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	generated := new(MockStrategy).
+		ExpectVersion(db, 0, nil).
+		ExpectSetVersion(db, 1, nil)
+	Register(MockStrategyName, generated)
+
+	dbMock.ExpectBegin()
+	scheme.
+		On("Version").Return(1).
+		On("VersionStrategy").Return(MockStrategyName).
+		On("OnCreate", db).Return(nil)
+	dbMock.ExpectCommit()
+
+	_, err := PersistScheme(db, scheme)
+	assert.Nil(t, err, "PersistScheme must not return error on create")
+
+	generated.AssertExpectations(t)
 	scheme.AssertExpectations(t)
 }
 
@@ -270,8 +603,8 @@ type schemeMock struct {
 	mock.Mock
 }
 
-func (s *schemeMock) Version() int {
-	return s.Called().Int(0)
+func (s *schemeMock) Version() SchemeVersion {
+	return SchemeVersion(s.Called().Int(0))
 }
 
 func (s *schemeMock) VersionStrategy() string {
@@ -0,0 +1,46 @@
+package version
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TableCreator is implemented by strategies storing the version in a
+// table, to create it explicitly instead of lazily from Version or
+// SetVersion
+type TableCreator interface {
+	CreateVersionTable(db *sql.DB) error
+	VersionTableExists(db *sql.DB) (bool, error)
+}
+
+// WarmUp creates the version table of the strategy registered by
+// strategyName if the strategy implements TableCreator and the table
+// does not exist yet. It does nothing for other strategies
+func WarmUp(db *sql.DB, strategyName string) error {
+	return DefaultRegistry.WarmUp(db, strategyName)
+}
+
+// WarmUp is like the package level WarmUp but looks strategyName up in
+// r
+func (r *Registry) WarmUp(db *sql.DB, strategyName string) error {
+	var strategy Strategy
+
+	if db == nil {
+		return ErrNilDB
+	}
+
+	if strategy = r.lookup(strategyName); strategy == nil {
+		return fmt.Errorf("%w %q (forgotten import?)", ErrUnknownStrategy, strategyName)
+	}
+
+	creator, ok := strategy.(TableCreator)
+	if !ok {
+		return nil
+	}
+
+	exists, err := creator.VersionTableExists(db)
+	if err != nil || exists {
+		return err
+	}
+	return creator.CreateVersionTable(db)
+}
@@ -0,0 +1,84 @@
+package version
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmUpCreatesMissingTable(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	creator := new(tableCreatorMock)
+	Register("table", creator)
+	creator.
+		On("VersionTableExists", db).Return(false, nil).
+		On("CreateVersionTable", db).Return(nil)
+
+	err := WarmUp(db, "table")
+	assert.Nil(t, err, "WarmUp must not return error")
+	creator.AssertExpectations(t)
+}
+
+func TestWarmUpSkipsExistingTable(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	creator := new(tableCreatorMock)
+	Register("table", creator)
+	creator.On("VersionTableExists", db).Return(true, nil)
+
+	err := WarmUp(db, "table")
+	assert.Nil(t, err, "WarmUp must not return error")
+	creator.AssertExpectations(t)
+}
+
+func TestWarmUpExistsError(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	creator := new(tableCreatorMock)
+	Register("table", creator)
+	creator.On("VersionTableExists", db).Return(false, someError)
+
+	err := WarmUp(db, "table")
+	assert.Equal(t, someError, err, "VersionTableExists error must be passed out")
+	creator.AssertExpectations(t)
+}
+
+func TestWarmUpWithoutTableCreator(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	err := WarmUp(db, "fake")
+	assert.Nil(t, err, "WarmUp must do nothing for strategies without TableCreator")
+	strategy.AssertExpectations(t)
+}
+
+func TestWarmUpUnknownStrategy(t *testing.T) {
+	setup(t)
+	defer tearsDown(t)
+
+	err := WarmUp(db, "not_registered")
+	assert.True(t, errors.Is(err, ErrUnknownStrategy), "An error must be returned when a strategy is not registered")
+}
+
+/////////////////////////////////////////////////////
+// Stubs
+/////////////////////////////////////////////////////
+
+type tableCreatorMock struct {
+	versionStrategyMock
+}
+
+func (m *tableCreatorMock) CreateVersionTable(db *sql.DB) error {
+	return m.Called(db).Error(0)
+}
+
+func (m *tableCreatorMock) VersionTableExists(db *sql.DB) (bool, error) {
+	args := m.Called(db)
+	return args.Bool(0), args.Error(1)
+}